@@ -7,15 +7,20 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	remotelist "ifpb/remotelist/pkg"
 )
 
-func readLine(prompt string) string {
+func readLine(prompt string, def ...string) string {
 	fmt.Print(prompt)
 	r := bufio.NewReader(os.Stdin)
 	line, _ := r.ReadString('\n')
-	return strings.TrimSpace(line)
+	line = strings.TrimSpace(line)
+	if line == "" && len(def) > 0 {
+		return def[0]
+	}
+	return line
 }
 
 func main() {
@@ -31,9 +36,14 @@ func main() {
 	for {
 		fmt.Println("\n========== MENU ==========")
 		fmt.Println("1 - Selecionar/usar lista (informar list_id e operações)")
-		fmt.Println("2 - Criar/Inicializar lista (opcional)")
+		fmt.Println("2 - Criar lista (opcional: Append também cria)")
 		fmt.Println("3 - Ver todas as listas (debug)")
-		fmt.Println("4 - Sair")
+		fmt.Println("4 - Backup do servidor (salvar em arquivo)")
+		fmt.Println("5 - Restaurar backup (servidor vazio)")
+		fmt.Println("6 - Esperar elemento (pop bloqueante em uma ou mais listas)")
+		fmt.Println("7 - Apagar lista")
+		fmt.Println("8 - Ver list_ids existentes")
+		fmt.Println("9 - Sair")
 		opt := readLine("Escolha uma opção: ")
 
 		switch opt {
@@ -47,20 +57,19 @@ func main() {
 			operateOnList(client, listID)
 
 		case "2":
-			idStr := readLine("Digite list_id a criar/inicializar (inteiro): ")
+			idStr := readLine("Digite list_id a criar (inteiro): ")
 			listID, err := strconv.Atoi(idStr)
 			if err != nil {
 				fmt.Println("list_id inválido")
 				continue
 			}
-			var ok remotelist.AppendReply
-			args := remotelist.AppendArgs{ListID: listID, Value: 0}
-			if err := client.Call("RemoteList.Append", args, &ok); err != nil {
-				fmt.Println("Erro ao inicializar lista:", err)
+			var r remotelist.CreateListReply
+			if err := client.Call("RemoteList.CreateList", remotelist.CreateListArgs{ListID: listID}, &r); err != nil {
+				fmt.Println("Erro ao criar lista:", err)
+			} else if r.Created {
+				fmt.Println("Lista criada.")
 			} else {
-				var rem remotelist.RemoveReply
-				_ = client.Call("RemoteList.Remove", remotelist.RemoveArgs{ListID: listID}, &rem)
-				fmt.Println("Lista inicializada (se não existia).")
+				fmt.Println("Lista já existia.")
 			}
 
 		case "3":
@@ -75,6 +84,52 @@ func main() {
 			}
 
 		case "4":
+			path := readLine("Arquivo de destino [backup.rbak]: ")
+			if path == "" {
+				path = "backup.rbak"
+			}
+			if err := backup(client, path); err != nil {
+				fmt.Println("Erro no backup:", err)
+			}
+
+		case "5":
+			path := readLine("Arquivo de backup [backup.rbak]: ")
+			if path == "" {
+				path = "backup.rbak"
+			}
+			if err := restore(client, path); err != nil {
+				fmt.Println("Erro no restore:", err)
+			}
+
+		case "6":
+			blockingPop(client)
+
+		case "7":
+			idStr := readLine("Digite list_id a apagar (inteiro): ")
+			listID, err := strconv.Atoi(idStr)
+			if err != nil {
+				fmt.Println("list_id inválido")
+				continue
+			}
+			if readLine("Confirmar (s/N): ") != "s" {
+				continue
+			}
+			var r remotelist.DeleteListReply
+			if err := client.Call("RemoteList.DeleteList", remotelist.DeleteListArgs{ListID: listID}, &r); err != nil {
+				fmt.Println("Erro ao apagar lista:", err)
+			} else {
+				fmt.Printf("Lista apagada (%d elementos)\n", r.Size)
+			}
+
+		case "8":
+			var ids []int
+			if err := client.Call("RemoteList.ListIDs", struct{}{}, &ids); err != nil {
+				fmt.Println("Erro ao obter list_ids:", err)
+			} else {
+				fmt.Println("list_ids:", ids)
+			}
+
+		case "9":
 			fmt.Println("Encerrando cliente...")
 			return
 		default:
@@ -83,6 +138,89 @@ func main() {
 	}
 }
 
+// blockingPop espera um elemento na primeira das listas informadas que receber valores
+func blockingPop(client *rpc.Client) {
+	var ids []int
+	for _, f := range strings.Fields(readLine("list_ids separados por espaço: ")) {
+		id, err := strconv.Atoi(f)
+		if err != nil {
+			fmt.Println("list_id inválido:", f)
+			return
+		}
+		ids = append(ids, id)
+	}
+	secs, err := strconv.Atoi(readLine("Timeout em segundos (0 = sem limite) [10]: ", "10"))
+	if err != nil || secs < 0 {
+		fmt.Println("timeout inválido")
+		return
+	}
+	fmt.Println("Aguardando...")
+	var r remotelist.BlockingPopReply
+	args := remotelist.BlockingPopArgs{ListIDs: ids, Timeout: time.Duration(secs) * time.Second}
+	if err := client.Call("RemoteList.BlockingPop", args, &r); err != nil {
+		fmt.Println("Erro:", err)
+	} else if r.TimedOut {
+		fmt.Println("Timeout: nenhum valor chegou")
+	} else {
+		fmt.Printf("Valor %d retirado da lista %d\n", r.Value, r.ListID)
+	}
+}
+
+// backup baixa o backup do servidor em pedaços e grava em path
+func backup(client *rpc.Client, path string) error {
+	var begin remotelist.BackupBeginReply
+	if err := client.Call("RemoteList.BackupBegin", struct{}{}, &begin); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var off int64
+	for {
+		var chunk remotelist.BackupChunkReply
+		if err := client.Call("RemoteList.BackupChunk", remotelist.BackupChunkArgs{ID: begin.ID, Offset: off}, &chunk); err != nil {
+			return err
+		}
+		if _, err := f.Write(chunk.Data); err != nil {
+			return err
+		}
+		off += int64(len(chunk.Data))
+		if chunk.EOF {
+			fmt.Printf("Backup salvo em %s (%d bytes, snapshot em lsn=%d, até lsn=%d)\n", path, off, begin.LSN, chunk.LSN)
+			return f.Sync()
+		}
+	}
+}
+
+// restore envia o backup em path, em pedaços, para um servidor vazio
+func restore(client *rpc.Client, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var begin remotelist.RestoreBeginReply
+	if err := client.Call("RemoteList.RestoreBegin", struct{}{}, &begin); err != nil {
+		return err
+	}
+	for off := 0; off < len(data); off += remotelist.BackupChunkSize {
+		end := min(off+remotelist.BackupChunkSize, len(data))
+		args := remotelist.RestoreChunkArgs{ID: begin.ID, Offset: int64(off), Data: data[off:end]}
+		var rep remotelist.RestoreChunkReply
+		if err := client.Call("RemoteList.RestoreChunk", args, &rep); err != nil {
+			return err
+		}
+	}
+	var end remotelist.RestoreEndReply
+	if err := client.Call("RemoteList.RestoreEnd", remotelist.RestoreEndArgs{ID: begin.ID}, &end); err != nil {
+		return err
+	}
+	fmt.Printf("Backup restaurado: %d listas (até lsn=%d da origem)\n", end.Lists, end.LSN)
+	return nil
+}
+
 func operateOnList(client *rpc.Client, listID int) {
 	for {
 		fmt.Printf("\n---- Operando lista %d ----\n", listID)
@@ -90,7 +228,18 @@ func operateOnList(client *rpc.Client, listID int) {
 		fmt.Println("2 - Get (pegar posição i)")
 		fmt.Println("3 - Remove (remover último)")
 		fmt.Println("4 - Size")
-		fmt.Println("5 - Voltar")
+		fmt.Println("5 - Insert (inserir na posição i)")
+		fmt.Println("6 - Set (trocar valor da posição i)")
+		fmt.Println("7 - RemoveAt (remover da posição i)")
+		fmt.Println("8 - Listar (GetRange, índices negativos contam do fim)")
+		fmt.Println("9 - AppendMany (vários valores separados por espaço)")
+		fmt.Println("10 - PushFront (adicionar no início)")
+		fmt.Println("11 - PopFront (remover o primeiro)")
+		fmt.Println("12 - Clear (esvaziar a lista)")
+		fmt.Println("13 - Trim (manter só um trecho, índices como no Listar)")
+		fmt.Println("14 - Buscar valor (IndexOf, Contains e Count)")
+		fmt.Println("15 - Agregados (sum, min, max, avg, percentil)")
+		fmt.Println("16 - Voltar")
 		choice := readLine("Escolha: ")
 		switch choice {
 		case "1":
@@ -138,9 +287,216 @@ func operateOnList(client *rpc.Client, listID int) {
 				fmt.Printf("Tamanho: %d\n", rep.Size)
 			}
 		case "5":
+			idx, v, ok := readIndexValue()
+			if !ok {
+				continue
+			}
+			args := remotelist.InsertArgs{ListID: listID, Index: idx, Value: v}
+			var rep remotelist.InsertReply
+			if err := client.Call("RemoteList.Insert", args, &rep); err != nil {
+				fmt.Println("Erro ao inserir:", err)
+			} else {
+				fmt.Println("Inserido com sucesso.")
+			}
+		case "6":
+			idx, v, ok := readIndexValue()
+			if !ok {
+				continue
+			}
+			args := remotelist.SetArgs{ListID: listID, Index: idx, Value: v}
+			var rep remotelist.SetReply
+			if err := client.Call("RemoteList.Set", args, &rep); err != nil {
+				fmt.Println("Erro ao trocar valor:", err)
+			} else {
+				fmt.Printf("[%d]: %d -> %d\n", idx, rep.Old, v)
+			}
+		case "7":
+			idxStr := readLine("Índice (inteiro): ")
+			idx, err := strconv.Atoi(idxStr)
+			if err != nil {
+				fmt.Println("índice inválido")
+				continue
+			}
+			args := remotelist.RemoveAtArgs{ListID: listID, Index: idx}
+			var rep remotelist.RemoveAtReply
+			if err := client.Call("RemoteList.RemoveAt", args, &rep); err != nil {
+				fmt.Println("Erro ao remover:", err)
+			} else {
+				fmt.Printf("Removido [%d]: %d\n", idx, rep.Value)
+			}
+		case "8":
+			start, err1 := strconv.Atoi(readLine("Início [0]: ", "0"))
+			end, err2 := strconv.Atoi(readLine("Fim [-1]: ", "-1"))
+			if err1 != nil || err2 != nil {
+				fmt.Println("índice inválido")
+				continue
+			}
+			printRange(client, listID, start, end)
+		case "9":
+			var values []int
+			valid := true
+			for _, f := range strings.Fields(readLine("Valores: ")) {
+				v, err := strconv.Atoi(f)
+				if err != nil {
+					fmt.Println("valor inválido:", f)
+					valid = false
+					break
+				}
+				values = append(values, v)
+			}
+			if !valid {
+				continue
+			}
+			args := remotelist.AppendManyArgs{ListID: listID, Values: values}
+			var rep remotelist.AppendManyReply
+			if err := client.Call("RemoteList.AppendMany", args, &rep); err != nil {
+				fmt.Println("Erro ao adicionar:", err)
+			} else {
+				fmt.Printf("%d valores adicionados (tamanho agora %d).\n", len(values), rep.Size)
+			}
+		case "10":
+			v, err := strconv.Atoi(readLine("Valor a adicionar no início (inteiro): "))
+			if err != nil {
+				fmt.Println("valor inválido")
+				continue
+			}
+			args := remotelist.PushFrontArgs{ListID: listID, Value: v}
+			var rep remotelist.PushFrontReply
+			if err := client.Call("RemoteList.PushFront", args, &rep); err != nil {
+				fmt.Println("Erro ao adicionar:", err)
+			} else {
+				fmt.Println("Adicionado no início.")
+			}
+		case "11":
+			args := remotelist.PopFrontArgs{ListID: listID}
+			var rep remotelist.PopFrontReply
+			if err := client.Call("RemoteList.PopFront", args, &rep); err != nil {
+				fmt.Println("Erro ao remover:", err)
+			} else {
+				fmt.Printf("Removido do início: %d\n", rep.Value)
+			}
+		case "12":
+			var rep remotelist.ClearReply
+			if err := client.Call("RemoteList.Clear", remotelist.ClearArgs{ListID: listID}, &rep); err != nil {
+				fmt.Println("Erro ao esvaziar:", err)
+			} else {
+				fmt.Printf("Removidos %d elementos\n", rep.Removed)
+			}
+		case "13":
+			start, err1 := strconv.Atoi(readLine("Início: "))
+			end, err2 := strconv.Atoi(readLine("Fim [-1]: ", "-1"))
+			if err1 != nil || err2 != nil {
+				fmt.Println("índice inválido")
+				continue
+			}
+			args := remotelist.TrimArgs{ListID: listID, Start: start, End: end}
+			var rep remotelist.TrimReply
+			if err := client.Call("RemoteList.Trim", args, &rep); err != nil {
+				fmt.Println("Erro no trim:", err)
+			} else {
+				fmt.Printf("Removidos %d elementos, restam %d\n", rep.Removed, rep.Size)
+			}
+		case "14":
+			v, err := strconv.Atoi(readLine("Valor a buscar (inteiro): "))
+			if err != nil {
+				fmt.Println("valor inválido")
+				continue
+			}
+			from, err := strconv.Atoi(readLine("A partir do índice [0]: ", "0"))
+			if err != nil {
+				fmt.Println("índice inválido")
+				continue
+			}
+			var idx remotelist.IndexOfReply
+			if err := client.Call("RemoteList.IndexOf", remotelist.IndexOfArgs{ListID: listID, Value: v, FromIndex: from}, &idx); err != nil {
+				fmt.Println("Erro na busca:", err)
+				continue
+			}
+			var cnt remotelist.CountReply
+			if err := client.Call("RemoteList.Count", remotelist.CountArgs{ListID: listID, Value: v}, &cnt); err != nil {
+				fmt.Println("Erro na busca:", err)
+				continue
+			}
+			if idx.Index < 0 {
+				fmt.Printf("%d não encontrado a partir de %d (%d ocorrências na lista)\n", v, from, cnt.Count)
+			} else {
+				fmt.Printf("%d encontrado na posição %d (%d ocorrências na lista)\n", v, idx.Index, cnt.Count)
+			}
+		case "15":
+			aggregate(client, listID)
+		case "16":
 			return
 		default:
 			fmt.Println("Opção inválida")
 		}
 	}
 }
+
+// aggregate mostra sum, min, max e avg da lista (ou de um trecho) e, se pedido, um percentil
+func aggregate(client *rpc.Client, listID int) {
+	args := remotelist.AggregateArgs{ListID: listID}
+	if r := readLine("Trecho (início fim) [lista inteira]: "); r != "" {
+		var start, end int
+		if _, err := fmt.Sscan(r, &start, &end); err != nil {
+			fmt.Println("trecho inválido")
+			return
+		}
+		args.Range = &remotelist.ListRange{Start: start, End: end}
+	}
+	for _, kind := range []string{"sum", "min", "max", "avg"} {
+		args.Kind = kind
+		var rep remotelist.AggregateReply
+		if err := client.Call("RemoteList.Aggregate", args, &rep); err != nil {
+			fmt.Printf("%s: erro: %v\n", kind, err)
+		} else {
+			fmt.Printf("%s = %g (%d elementos)\n", kind, rep.Value, rep.Count)
+		}
+	}
+	if p := readLine("Percentil (0-100, vazio = nenhum): "); p != "" {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			fmt.Println("percentil inválido")
+			return
+		}
+		args.Kind, args.Percentile = "percentile", v
+		var rep remotelist.AggregateReply
+		if err := client.Call("RemoteList.Aggregate", args, &rep); err != nil {
+			fmt.Println("Erro:", err)
+		} else {
+			fmt.Printf("p%g = %g\n", v, rep.Value)
+		}
+	}
+}
+
+// readIndexValue lê índice e valor para Insert/Set
+func readIndexValue() (int, int, bool) {
+	idx, err := strconv.Atoi(readLine("Índice (inteiro): "))
+	if err != nil {
+		fmt.Println("índice inválido")
+		return 0, 0, false
+	}
+	v, err := strconv.Atoi(readLine("Valor (inteiro): "))
+	if err != nil {
+		fmt.Println("valor inválido")
+		return 0, 0, false
+	}
+	return idx, v, true
+}
+
+// printRange mostra o trecho pedido, uma página por chamada de GetRange
+func printRange(client *rpc.Client, listID, start, end int) {
+	args := remotelist.GetRangeArgs{ListID: listID, Start: start, End: end}
+	for {
+		var rep remotelist.GetRangeReply
+		if err := client.Call("RemoteList.GetRange", args, &rep); err != nil {
+			fmt.Println("Erro ao listar:", err)
+			return
+		}
+		fmt.Println(rep.Values)
+		if !rep.More {
+			fmt.Printf("(lista com %d elementos)\n", rep.Size)
+			return
+		}
+		args.Start = rep.Next
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	remotelist "ifpb/remotelist/pkg"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `uso: remotelist-inspect [flags] <comando>
+
+comandos:
+  stats           resumo do snapshot e do log (entradas por lista, por operação, período)
+  log             imprime as entradas do log, uma por linha
+  verify          confere o log (LSNs, checksums) e se o replay reproduz os snapshots
+  diff <a> <b>    compara dois arquivos de snapshot
+
+flags:`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	base := flag.String("base", "lista_dados", "prefixo dos arquivos (lista_dados.snapshot, lista_dados.log.*)")
+	listFlag := flag.Int("list", 0, "considera só este list_id (stats e log); sem a flag, todas as listas")
+	flag.Usage = usage
+	flag.Parse()
+
+	//list_id pode ser qualquer int, inclusive negativo: o filtro vale só se a flag foi passada
+	listSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "list" {
+			listSet = true
+		}
+	})
+	filter := func(id int) bool { return !listSet || id == *listFlag }
+
+	var err error
+	switch flag.Arg(0) {
+	case "stats":
+		err = stats(*base, filter)
+	case "log":
+		err = printLog(*base, filter)
+	case "verify":
+		var ok bool
+		ok, err = verify(*base)
+		if err == nil && !ok {
+			os.Exit(1)
+		}
+	case "diff":
+		if flag.NArg() != 3 {
+			usage()
+			os.Exit(2)
+		}
+		var same bool
+		same, err = diff(flag.Arg(1), flag.Arg(2))
+		if err == nil && !same {
+			os.Exit(1)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Println("Erro:", err)
+		os.Exit(1)
+	}
+}
+
+// flatten abre as entradas batch nas suas operações, com o LSN e o timestamp do lote
+func flatten(entries []remotelist.LogEntry) []remotelist.LogEntry {
+	out := make([]remotelist.LogEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Operation != "batch" {
+			out = append(out, e)
+			continue
+		}
+		for _, op := range e.Ops {
+			op.LSN, op.Timestamp = e.LSN, e.Timestamp
+			op.Operation = "batch/" + op.Operation
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+func fmtTime(ts int64) string {
+	if ts == 0 {
+		return "-"
+	}
+	return time.Unix(0, ts).Format(time.RFC3339Nano)
+}
+
+// --- stats ---
+func stats(base string, filter func(int) bool) error {
+	snap, err := remotelist.ReadSnapshotFile(base + ".snapshot")
+	switch {
+	case os.IsNotExist(err):
+		fmt.Println("snapshot: nenhum")
+	case err != nil:
+		fmt.Println("snapshot: ilegível:", err)
+	default:
+		lists, values := 0, 0
+		for id, ls := range snap.Lists {
+			if filter(id) {
+				lists++
+				values += len(ls)
+			}
+		}
+		fmt.Printf("snapshot: lsn=%d %s, %d listas, %d valores\n", snap.LSN, fmtTime(snap.Timestamp), lists, values)
+	}
+	if paths, err := remotelist.ListSnapshots(base); err == nil && len(paths) > 1 {
+		fmt.Printf("histórico: %d snapshots anteriores\n", len(paths)-1)
+	}
+
+	segs, err := remotelist.ReadLog(base)
+	if err != nil {
+		return err
+	}
+	perList := make(map[int]int)
+	perOp := make(map[string]int)
+	var total int
+	var firstTS, lastTS int64
+	var firstLSN, lastLSN uint64
+	for _, seg := range segs {
+		status := "ok"
+		if seg.Corruption != nil {
+			status = "CORROMPIDO: " + seg.Corruption.Reason
+		}
+		fmt.Printf("segmento %s: %s, %d bytes, %d entradas, %s\n", seg.Path, seg.Format, seg.Bytes, len(seg.Entries), status)
+		for _, e := range flatten(seg.Entries) {
+			if !filter(e.ListID) {
+				continue
+			}
+			total++
+			perList[e.ListID]++
+			perOp[e.Operation]++
+			if firstTS == 0 || e.Timestamp < firstTS {
+				firstTS = e.Timestamp
+			}
+			if e.Timestamp > lastTS {
+				lastTS = e.Timestamp
+			}
+			if e.LSN > 0 && (firstLSN == 0 || e.LSN < firstLSN) {
+				firstLSN = e.LSN
+			}
+			if e.LSN > lastLSN {
+				lastLSN = e.LSN
+			}
+		}
+	}
+	fmt.Printf("log: %d entradas, lsn %d..%d, de %s até %s\n", total, firstLSN, lastLSN, fmtTime(firstTS), fmtTime(lastTS))
+
+	ops := make([]string, 0, len(perOp))
+	for op := range perOp {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	fmt.Println("por operação:")
+	for _, op := range ops {
+		fmt.Printf("  %-10s %d\n", op, perOp[op])
+	}
+	ids := make([]int, 0, len(perList))
+	for id := range perList {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	fmt.Println("por lista:")
+	for _, id := range ids {
+		fmt.Printf("  %-10d %d\n", id, perList[id])
+	}
+	return nil
+}
+
+// --- log ---
+func printLog(base string, filter func(int) bool) error {
+	segs, err := remotelist.ReadLog(base)
+	if err != nil {
+		return err
+	}
+	for _, seg := range segs {
+		for _, e := range flatten(seg.Entries) {
+			if filter(e.ListID) {
+				fmt.Printf("%d\t%s\t%s\tlist=%d\tindex=%d\tvalue=%d", e.LSN, fmtTime(e.Timestamp), e.Operation, e.ListID, e.Index, e.Value)
+				if e.End != 0 {
+					fmt.Printf("\tend=%d", e.End)
+				}
+				if len(e.Values) > 0 {
+					fmt.Printf("\tvalues=%v", e.Values)
+				}
+				fmt.Println()
+			}
+		}
+		if seg.Corruption != nil {
+			fmt.Printf("# %s: %s\n", seg.Path, seg.Corruption.Reason)
+		}
+	}
+	return nil
+}
+
+// --- verify ---
+// confere a sequência de LSNs e, partindo do snapshot mais antigo, se o replay
+// do log chega ao conteúdo de cada snapshot mais novo (menos os gravados por uma
+// restauração, marcada no log por uma entrada "restore")
+func verify(base string) (bool, error) {
+	ok := true
+	problem := func(format string, args ...any) {
+		ok = false
+		fmt.Printf("PROBLEMA: "+format+"\n", args...)
+	}
+
+	segs, err := remotelist.ReadLog(base)
+	if err != nil {
+		return false, err
+	}
+	var entries []remotelist.LogEntry
+	var prevLSN uint64
+	var prevTS int64
+	for i, seg := range segs {
+		if c := seg.Corruption; c != nil {
+			if i == len(segs)-1 && c.ValidAfter == 0 {
+				fmt.Printf("aviso: %s termina com registro incompleto (%s); o servidor trunca na carga\n", seg.Path, c.Reason)
+			} else {
+				problem("%v", c)
+			}
+		}
+		for _, e := range seg.Entries {
+			if e.LSN != 0 {
+				if prevLSN != 0 && e.LSN != prevLSN+1 {
+					problem("%s: lsn %d depois de %d", seg.Path, e.LSN, prevLSN)
+				}
+				prevLSN = e.LSN
+			}
+			if e.Timestamp < prevTS {
+				//a ordem é dada pelo LSN; relógio voltando (ajuste de NTP) só afeta a recuperação por horário
+				fmt.Printf("aviso: %s: lsn %d com timestamp anterior à entrada de antes (relógio ajustado?)\n", seg.Path, e.LSN)
+			}
+			prevTS = e.Timestamp
+			entries = append(entries, e)
+		}
+	}
+	fmt.Printf("log: %d segmentos, %d entradas\n", len(segs), len(entries))
+
+	paths, err := remotelist.ListSnapshots(base)
+	if err != nil {
+		return false, err
+	}
+	var snaps []*remotelist.Snapshot
+	for _, p := range paths {
+		snap, err := remotelist.ReadSnapshotFile(p)
+		if err != nil {
+			problem("%s ilegível: %v", p, err)
+			snaps = append(snaps, nil)
+			continue
+		}
+		snaps = append(snaps, snap)
+	}
+
+	//o log precisa continuar de onde o snapshot atual parou
+	if n := len(snaps); n > 0 && snaps[n-1] != nil && len(entries) > 0 {
+		first := entries[0].LSN
+		if first > snaps[n-1].LSN+1 {
+			problem("log começa em lsn %d, mas o snapshot atual termina em %d", first, snaps[n-1].LSN)
+		}
+	}
+
+	//replay entre snapshots consecutivos do histórico
+	for i := 0; i+1 < len(snaps); i++ {
+		from, to := snaps[i], snaps[i+1]
+		if from == nil || to == nil {
+			continue
+		}
+		var span []remotelist.LogEntry
+		for _, e := range entries {
+			if e.LSN > from.LSN && e.LSN <= to.LSN {
+				span = append(span, e)
+			}
+		}
+		if uint64(len(span)) != to.LSN-from.LSN {
+			fmt.Printf("aviso: log de %d..%d não está mais no disco; %s não conferido\n", from.LSN+1, to.LSN, paths[i+1])
+			continue
+		}
+		//depois de uma restauração o estado vem do snapshot, não do log
+		if restore := restoreIn(span); restore != 0 {
+			fmt.Printf("aviso: lsn %d é uma restauração; %s não conferido pelo replay\n", restore, paths[i+1])
+			continue
+		}
+		got, err := remotelist.Replay(from, span)
+		if err != nil {
+			return false, err
+		}
+		if d := diffLists(got.Lists, to.Lists); len(d) > 0 {
+			problem("replay de %s + %d entradas não reproduz %s:", paths[i], len(span), paths[i+1])
+			for _, line := range d {
+				fmt.Println("  " + line)
+			}
+		} else {
+			fmt.Printf("ok: %s + %d entradas = %s\n", paths[i], len(span), paths[i+1])
+		}
+	}
+
+	//e o estado final (snapshot atual + log) precisa ser reconstruível
+	if _, err := remotelist.RecoverState(base, remotelist.RecoveryTarget{}); err != nil {
+		problem("estado final: %v", err)
+	}
+	if ok {
+		fmt.Println("nenhum problema encontrado")
+	}
+	return ok, nil
+}
+
+// restoreIn retorna o LSN da última restauração em entries (0 se não houver)
+func restoreIn(entries []remotelist.LogEntry) uint64 {
+	var lsn uint64
+	for _, e := range entries {
+		if e.Operation == "restore" {
+			lsn = e.LSN
+		}
+	}
+	return lsn
+}
+
+// --- diff ---
+func diff(a, b string) (bool, error) {
+	sa, err := remotelist.ReadSnapshotFile(a)
+	if err != nil {
+		return false, err
+	}
+	sb, err := remotelist.ReadSnapshotFile(b)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("a: %s lsn=%d %s, %d listas\n", a, sa.LSN, fmtTime(sa.Timestamp), len(sa.Lists))
+	fmt.Printf("b: %s lsn=%d %s, %d listas\n", b, sb.LSN, fmtTime(sb.Timestamp), len(sb.Lists))
+	d := diffLists(sa.Lists, sb.Lists)
+	for _, line := range d {
+		fmt.Println(line)
+	}
+	if len(d) == 0 {
+		fmt.Println("conteúdo idêntico")
+	}
+	return len(d) == 0, nil
+}
+
+// diffLists descreve, por list_id, as diferenças entre a e b
+func diffLists(a, b map[int][]int) []string {
+	idSet := make(map[int]bool)
+	for id := range a {
+		idSet[id] = true
+	}
+	for id := range b {
+		idSet[id] = true
+	}
+	ids := make([]int, 0, len(idSet))
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var out []string
+	for _, id := range ids {
+		la, inA := a[id]
+		lb, inB := b[id]
+		switch {
+		case !inA:
+			out = append(out, fmt.Sprintf("+ lista %d: só em b (%d valores)", id, len(lb)))
+		case !inB:
+			out = append(out, fmt.Sprintf("- lista %d: só em a (%d valores)", id, len(la)))
+		default:
+			i := 0
+			for i < len(la) && i < len(lb) && la[i] == lb[i] {
+				i++
+			}
+			if i == len(la) && i == len(lb) {
+				continue
+			}
+			out = append(out, fmt.Sprintf("~ lista %d: %d -> %d valores, primeira diferença no índice %d", id, len(la), len(lb), i))
+		}
+	}
+	return out
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"net"
 	"net/rpc"
@@ -15,13 +16,124 @@ import (
 const basePath = "lista_dados"
 
 func main() {
+	syncFlag := flag.String("sync", "os", "durabilidade do log: always (fsync por operação), interval ou os")
+	syncInterval := flag.Duration("sync-interval", 100*time.Millisecond, "intervalo entre fsyncs no modo interval")
+	formatFlag := flag.String("format", "json", "formato de novos snapshots e segmentos de log: json ou binary")
+	snapInterval := flag.Duration("snapshot-interval", 30*time.Second, "snapshot no máximo a cada intervalo (0 desliga)")
+	snapRecords := flag.Uint64("snapshot-records", 0, "snapshot a cada N entradas de log (0 desliga)")
+	snapBytes := flag.Int64("snapshot-bytes", 0, "snapshot quando o log passar de N bytes (0 desliga)")
+	segmentSize := flag.Int64("segment-size", 64<<20, "tamanho máximo de cada segmento do log, em bytes (0 = só troca nos snapshots)")
+	maxPage := flag.Int("max-page", 1000, "máximo de elementos por chamada de GetRange")
+	repair := flag.Bool("repair", false, "trunca o log no primeiro registro corrompido em vez de recusar a inicialização")
+	ignoreBadSnap := flag.Bool("ignore-bad-snapshot", false, "sobe só com o log se o snapshot atual estiver ilegível (perde o que só estava nele)")
+	snapKeep := flag.Int("snapshot-keep", 1, "quantos snapshots manter (o atual mais os anteriores com timestamp no nome)")
+	snapGzip := flag.Bool("snapshot-gzip", false, "comprime os snapshots com gzip")
+	fromSnapshot := flag.String("from-snapshot", "", "inicia a partir deste snapshot do histórico (ver -list-snapshots)")
+	listSnapshots := flag.Bool("list-snapshots", false, "lista os snapshots disponíveis e sai")
+	legacyFile := flag.String("legacy-file", "lista_dados.json", "arquivo do servidor antigo (lista única) a importar, se existir; vazio desliga")
+	legacyListID := flag.Int("legacy-list-id", 0, "list_id que recebe os valores do arquivo antigo")
+	recoverLSN := flag.Uint64("recover-lsn", 0, "reconstrói o estado até esta posição do log (inclusive) e sobe com ele")
+	recoverTime := flag.String("recover-time", "", "reconstrói o estado como estava neste instante (RFC3339) e sobe com ele")
+	flag.Parse()
+
+	target := remotelist.RecoveryTarget{LSN: *recoverLSN}
+	if *recoverTime != "" {
+		t, err := time.Parse(time.RFC3339Nano, *recoverTime)
+		if err != nil {
+			fmt.Println("Erro: -recover-time:", err)
+			return
+		}
+		target.Time = t
+	}
+	// voltar no tempo substitui o estado atual; sem histórico ele se perderia
+	if (*fromSnapshot != "" || target != (remotelist.RecoveryTarget{})) && *snapKeep < 2 {
+		fmt.Println("Erro: -from-snapshot e -recover-* precisam de -snapshot-keep 2 ou mais, para o estado atual ficar no histórico")
+		return
+	}
+
+	if *listSnapshots {
+		paths, err := remotelist.ListSnapshots(basePath)
+		if err != nil {
+			fmt.Println("Erro:", err)
+			os.Exit(1)
+		}
+		for _, p := range paths {
+			snap, err := remotelist.ReadSnapshotFile(p)
+			if err != nil {
+				fmt.Printf("%s\t(ilegível: %v)\n", p, err)
+				continue
+			}
+			fmt.Printf("%s\tlsn=%d\t%s\t%d listas\n", p, snap.LSN,
+				time.Unix(0, snap.Timestamp).Format(time.RFC3339), len(snap.Lists))
+		}
+		return
+	}
+
+	syncMode, err := remotelist.ParseSyncMode(*syncFlag)
+	if err != nil {
+		fmt.Println("Erro:", err)
+		return
+	}
+
+	format, err := remotelist.ParseFormat(*formatFlag)
+	if err != nil {
+		fmt.Println("Erro:", err)
+		return
+	}
+
 	fmt.Println("Servidor iniciando...")
 
-	rl := remotelist.NewRemoteListWithBase(basePath)
+	rl := remotelist.NewRemoteListWithBase(basePath,
+		remotelist.WithSync(syncMode, *syncInterval),
+		remotelist.WithRepair(*repair),
+		remotelist.WithIgnoreBadSnapshot(*ignoreBadSnap),
+		remotelist.WithFormat(format),
+		remotelist.WithSnapshotHistory(*snapKeep, *snapGzip),
+		remotelist.WithSegmentSize(*segmentSize),
+		remotelist.WithMaxPageSize(*maxPage),
+	)
 
-	// carregar estado (snapshot + log)
+	// carregar estado (snapshot + log); com o log ou o snapshot corrompido o servidor não sobe
 	if err := rl.LoadFromSnapshot(); err != nil {
 		fmt.Println("Erro ao carregar snapshot/log:", err)
+		os.Exit(1)
+	}
+
+	// importar o arquivo do servidor antigo, se ainda não foi migrado
+	if *legacyFile != "" {
+		migrated, err := rl.MigrateLegacy(*legacyFile, *legacyListID)
+		if err != nil {
+			fmt.Println("Erro ao migrar", *legacyFile+":", err)
+			os.Exit(1)
+		}
+		if migrated {
+			fmt.Printf("%s importado para a lista %d (renomeado para %s.migrated)\n", *legacyFile, *legacyListID, *legacyFile)
+		}
+	}
+
+	// voltar a um snapshot antigo: o estado atual é gravado como snapshot e arquivado
+	if *fromSnapshot != "" {
+		if err := rl.RestoreSnapshot(*fromSnapshot); err != nil {
+			fmt.Println("Erro ao restaurar snapshot:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Estado restaurado de", *fromSnapshot)
+	}
+
+	// recuperação point-in-time: snapshot anterior ao ponto + replay do log até ele.
+	// O estado descartado é arquivado como snapshot (no LSN anterior ao do restore)
+	// e sai do histórico como os outros, conforme -snapshot-keep.
+	if target != (remotelist.RecoveryTarget{}) {
+		snap, err := remotelist.RecoverState(basePath, target)
+		if err != nil {
+			fmt.Println("Erro na recuperação:", err)
+			os.Exit(1)
+		}
+		if err := rl.Restore(snap); err != nil {
+			fmt.Println("Erro ao aplicar estado recuperado:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Estado recuperado até", target)
 	}
 
 	// registrar RPC
@@ -31,19 +143,12 @@ func main() {
 		return
 	}
 
-	// goroutine que cria snapshots periódicos
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			fmt.Println("[Snapshot] iniciando...")
-			if err := rl.CreateSnapshot(); err != nil {
-				fmt.Println("[Snapshot] erro ao criar snapshot:", err)
-			} else {
-				fmt.Println("[Snapshot] snapshot criado com sucesso")
-			}
-		}
-	}()
+	// goroutine que cria snapshots conforme a política (tempo, entradas ou bytes de log)
+	go rl.RunSnapshotter(remotelist.SnapshotPolicy{
+		EveryRecords: *snapRecords,
+		EveryBytes:   *snapBytes,
+		Interval:     *snapInterval,
+	}, nil)
 
 	// capturar sinais para snapshot final
 	sigs := make(chan os.Signal, 1)
@@ -56,6 +161,9 @@ func main() {
 		} else {
 			fmt.Println("[Server] snapshot final criado")
 		}
+		if err := rl.Close(); err != nil {
+			fmt.Println("[Server] erro ao fechar log:", err)
+		}
 		os.Exit(0)
 	}()
 
@@ -0,0 +1,103 @@
+package remotelist
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// --- agregados (calculados no servidor, com o lock da lista) ---
+// Sobre a lista inteira, sum e avg são O(1) e min/max também, a não ser logo
+// depois de remover o mínimo ou o máximo (ver deque). Sobre um trecho, ou no
+// percentil, os valores do trecho são percorridos.
+
+// ListRange é um trecho no estilo de GetRange: Start e End inclusivos,
+// negativos contam do fim
+type ListRange struct {
+	Start int
+	End   int
+}
+
+type AggregateArgs struct {
+	ListID     int
+	Kind       string     //sum, min, max, avg ou percentile
+	Percentile float64    //percentile: de 0 a 100 (50 = mediana)
+	Range      *ListRange //nil = lista inteira
+}
+type AggregateReply struct {
+	Value float64
+	Count int //quantos elementos entraram no cálculo
+}
+
+// Aggregate: calcula Kind sobre a lista list_id (ou o trecho Range dela)
+func (rl *RemoteList) Aggregate(args AggregateArgs, reply *AggregateReply) error {
+	switch args.Kind {
+	case "sum", "min", "max", "avg":
+	case "percentile":
+		if args.Percentile < 0 || args.Percentile > 100 || math.IsNaN(args.Percentile) {
+			return fmt.Errorf("percentil %v fora de 0..100", args.Percentile)
+		}
+	default:
+		return fmt.Errorf("agregado desconhecido: %q", args.Kind)
+	}
+
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	rl.mu.RLock()
+	d, ok := rl.lists[args.ListID]
+	rl.mu.RUnlock()
+	if !ok {
+		return errNoList
+	}
+
+	if args.Range != nil {
+		from, to := resolveRange(d.len(), args.Range.Start, args.Range.End)
+		d = newDeque(d.values()[from:to]) //agregados do trecho, sem copiar os valores
+	} else if !d.extremaOK && (args.Kind == "min" || args.Kind == "max") {
+		//recalcula e guarda; com o lock da lista travado ninguém mais altera d
+		d.refreshExtrema()
+		rl.mu.Lock()
+		rl.lists[args.ListID] = d
+		rl.mu.Unlock()
+	}
+
+	n := d.len()
+	reply.Count = n
+	if n == 0 {
+		if args.Kind == "sum" {
+			return nil
+		}
+		return errEmpty
+	}
+	switch args.Kind {
+	case "sum":
+		reply.Value = float64(d.sum)
+	case "avg":
+		reply.Value = float64(d.sum) / float64(n)
+	case "min":
+		d.refreshExtrema()
+		reply.Value = float64(d.min)
+	case "max":
+		d.refreshExtrema()
+		reply.Value = float64(d.max)
+	case "percentile":
+		reply.Value = percentile(d.values(), args.Percentile)
+	}
+	return nil
+}
+
+// percentile interpola entre os dois valores mais próximos da posição p/100*(n-1)
+// na lista ordenada (p=0 é o mínimo, p=100 o máximo). ls não é alterado.
+func percentile(ls []int, p float64) float64 {
+	sorted := slices.Clone(ls)
+	slices.Sort(sorted)
+	pos := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	frac := pos - float64(lo)
+	return float64(sorted[lo]) + frac*float64(sorted[hi]-sorted[lo])
+}
@@ -0,0 +1,356 @@
+package remotelist
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// --- backup/restore online (RPCs administrativos) ---
+// O backup é uma sequência de bytes baixada em pedaços:
+//
+//	"RBAK" versão | uvarint(tamanho) snapshot binário | segmento de log binário ("RLOG" + registros)
+//
+// BackupBegin captura o snapshot (copy-on-write, sem parar os handlers) e passa
+// a guardar as entradas confirmadas depois dele. Quando o cliente termina de
+// baixar o snapshot, o backup é selado no LSN corrente e o resto do log vai no
+// final; o backup representa o estado do momento em que foi selado.
+
+// BackupChunkSize é o tamanho máximo de cada pedaço, nos dois sentidos
+const BackupChunkSize = 256 << 10
+
+// sessões sem uso por mais que isso são descartadas
+const backupSessionTTL = 2 * time.Minute
+
+// máximo de entradas guardadas por um backup ainda não selado; um cliente que
+// baixa devagar demais perde o backup em vez de fazer a memória crescer sem limite
+const backupMaxTail = 1 << 20
+
+// máximo de backups em andamento: cada um guarda uma cópia do snapshot (e do log
+// que vai junto) até terminar de ser baixado
+const backupMaxSessions = 2
+
+var backupMagic = []byte("RBAK")
+
+var errTooManyBackups = fmt.Errorf("já há %d backups em andamento; termine um ou espere expirar", backupMaxSessions)
+
+type BackupBeginReply struct {
+	ID  uint64
+	LSN uint64 //LSN do snapshot capturado
+}
+
+type BackupChunkArgs struct {
+	ID     uint64
+	Offset int64
+}
+type BackupChunkReply struct {
+	Data []byte
+	EOF  bool
+	LSN  uint64 //no último pedaço: LSN até onde o backup vai
+}
+
+type RestoreBeginReply struct {
+	ID uint64
+}
+
+type RestoreChunkArgs struct {
+	ID     uint64
+	Offset int64
+	Data   []byte
+}
+type RestoreChunkReply struct {
+	Received int64
+}
+
+type RestoreEndArgs struct {
+	ID uint64
+}
+type RestoreEndReply struct {
+	LSN   uint64 //LSN do servidor de origem até onde o backup vai
+	Lists int
+}
+
+type backupSession struct {
+	snapLSN  uint64
+	data     []byte     //bytes a enviar (o snapshot; depois de selado, também o log)
+	sealed   bool       //log anexado; tapBackups não guarda mais nada
+	tail     []LogEntry //entradas confirmadas desde o início (pode vir fora de ordem)
+	lsn      uint64     //LSN final, depois de selado
+	lastUsed time.Time
+}
+
+type restoreSession struct {
+	data     []byte
+	lastUsed time.Time
+}
+
+// BackupBegin inicia um backup; os bytes são obtidos com BackupChunk
+func (rl *RemoteList) BackupBegin(_ struct{}, reply *BackupBeginReply) error {
+	//registrada antes da captura, a sessão não perde nenhuma entrada posterior ao snapshot
+	s := &backupSession{lastUsed: time.Now()}
+	rl.backupMu.Lock()
+	rl.expireSessionsLocked()
+	if len(rl.backups) >= backupMaxSessions {
+		rl.backupMu.Unlock()
+		return errTooManyBackups
+	}
+	rl.nextSession++
+	id := rl.nextSession
+	rl.backups[id] = s
+	rl.backupsActive.Add(1)
+	rl.backupMu.Unlock()
+
+	snap := rl.captureSnapshot()
+	body, err := encodeSnapshot(snap, FormatBinary)
+	if err != nil {
+		rl.backupMu.Lock()
+		rl.dropBackupLocked(id)
+		rl.backupMu.Unlock()
+		return err
+	}
+	data := binaryHeader(backupMagic)
+	data = binary.AppendUvarint(data, uint64(len(body)))
+	data = append(data, body...)
+
+	rl.backupMu.Lock()
+	s.snapLSN = snap.LSN
+	s.data = data
+	rl.backupMu.Unlock()
+
+	reply.ID = id
+	reply.LSN = snap.LSN
+	return nil
+}
+
+// BackupChunk devolve os bytes a partir de Offset; EOF marca o último pedaço
+func (rl *RemoteList) BackupChunk(args BackupChunkArgs, reply *BackupChunkReply) error {
+	rl.backupMu.Lock()
+	rl.expireSessionsLocked()
+	s, ok := rl.backups[args.ID]
+	if ok && !s.sealed && args.Offset >= int64(len(s.data)) {
+		//snapshot todo entregue: sela (fora do backupMu, que commit usa com snapshotRW.RLock)
+		rl.backupMu.Unlock()
+		rl.sealBackup(s)
+		rl.backupMu.Lock()
+	}
+	defer rl.backupMu.Unlock()
+	if !ok {
+		return fmt.Errorf("backup %d desconhecido ou expirado", args.ID)
+	}
+	if args.Offset < 0 || args.Offset > int64(len(s.data)) {
+		return fmt.Errorf("offset %d fora do backup (%d bytes)", args.Offset, len(s.data))
+	}
+	s.lastUsed = time.Now()
+	end := min(args.Offset+BackupChunkSize, int64(len(s.data)))
+	reply.Data = s.data[args.Offset:end]
+	if s.sealed && end == int64(len(s.data)) {
+		reply.EOF = true
+		reply.LSN = s.lsn
+		rl.dropBackupLocked(args.ID)
+	}
+	return nil
+}
+
+// sealBackup anexa ao backup o log entre o snapshot e o LSN corrente
+func (rl *RemoteList) sealBackup(s *backupSession) {
+	//com snapshotRW travado não há commit em andamento: tudo até lastLSN já passou por tapBackups
+	rl.snapshotRW.Lock()
+	defer rl.snapshotRW.Unlock()
+	rl.mu.RLock()
+	lsn := rl.lastLSN
+	rl.mu.RUnlock()
+
+	rl.backupMu.Lock()
+	defer rl.backupMu.Unlock()
+	if s.sealed {
+		return
+	}
+	sort.Slice(s.tail, func(i, j int) bool { return s.tail[i].LSN < s.tail[j].LSN })
+	s.data = append(s.data, binaryHeader(logMagic)...)
+	for i := range s.tail {
+		e := &s.tail[i]
+		if e.LSN <= s.snapLSN || e.LSN > lsn {
+			continue
+		}
+		rec, err := encodeEntryBinary(e)
+		if err != nil {
+			//operação sem código binário não deveria existir; o restore acusaria a falta da entrada
+			fmt.Println("[Backup] entrada ignorada:", err)
+			continue
+		}
+		s.data = append(s.data, rec...)
+	}
+	s.tail = nil
+	s.sealed = true
+	s.lsn = lsn
+	rl.backupsActive.Add(-1)
+}
+
+// tapBackups guarda uma entrada recém-confirmada nos backups ainda não selados.
+// Backups abandonados (cliente caiu no meio do download) ou com a cauda cheia são
+// descartados aqui mesmo: senão cada commit continuaria guardando entradas para eles.
+func (rl *RemoteList) tapBackups(entry LogEntry) {
+	rl.backupMu.Lock()
+	defer rl.backupMu.Unlock()
+	now := time.Now()
+	for id, s := range rl.backups {
+		if s.sealed {
+			continue
+		}
+		switch {
+		case now.Sub(s.lastUsed) > backupSessionTTL:
+			rl.dropBackupLocked(id)
+		case len(s.tail) >= backupMaxTail:
+			fmt.Printf("[Backup] backup %d descartado: mais de %d entradas esperando o fim do download\n", id, backupMaxTail)
+			rl.dropBackupLocked(id)
+		default:
+			s.tail = append(s.tail, entry)
+		}
+	}
+}
+
+// dropBackupLocked descarta a sessão id. Assume backupMu travado.
+func (rl *RemoteList) dropBackupLocked(id uint64) {
+	if s, ok := rl.backups[id]; ok {
+		if !s.sealed {
+			rl.backupsActive.Add(-1)
+		}
+		delete(rl.backups, id)
+	}
+}
+
+// expireSessionsLocked descarta backups e restores abandonados. Assume backupMu travado.
+func (rl *RemoteList) expireSessionsLocked() {
+	for id, s := range rl.backups {
+		if time.Since(s.lastUsed) > backupSessionTTL {
+			rl.dropBackupLocked(id)
+		}
+	}
+	for id, s := range rl.restores {
+		if time.Since(s.lastUsed) > backupSessionTTL {
+			delete(rl.restores, id)
+		}
+	}
+}
+
+// --- restore ---
+
+var errNotEmpty = errors.New("restore só é permitido num servidor vazio")
+
+// RestoreBegin inicia o envio de um backup; só é aceito com o servidor vazio
+func (rl *RemoteList) RestoreBegin(_ struct{}, reply *RestoreBeginReply) error {
+	if rl.hasLists() {
+		return errNotEmpty
+	}
+	rl.backupMu.Lock()
+	defer rl.backupMu.Unlock()
+	rl.expireSessionsLocked()
+	rl.nextSession++
+	rl.restores[rl.nextSession] = &restoreSession{lastUsed: time.Now()}
+	reply.ID = rl.nextSession
+	return nil
+}
+
+// RestoreChunk recebe os bytes do backup em ordem; reenviar um pedaço já recebido é ignorado
+func (rl *RemoteList) RestoreChunk(args RestoreChunkArgs, reply *RestoreChunkReply) error {
+	rl.backupMu.Lock()
+	defer rl.backupMu.Unlock()
+	s, ok := rl.restores[args.ID]
+	if !ok {
+		return fmt.Errorf("restore %d desconhecido ou expirado", args.ID)
+	}
+	if len(args.Data) > BackupChunkSize {
+		return fmt.Errorf("pedaço de %d bytes maior que o máximo (%d)", len(args.Data), BackupChunkSize)
+	}
+	s.lastUsed = time.Now()
+	switch have := int64(len(s.data)); {
+	case args.Offset == have:
+		s.data = append(s.data, args.Data...)
+	case args.Offset+int64(len(args.Data)) <= have:
+		//reenvio
+	default:
+		return fmt.Errorf("offset %d inesperado (recebidos %d bytes)", args.Offset, have)
+	}
+	reply.Received = int64(len(s.data))
+	return nil
+}
+
+// RestoreEnd valida o backup recebido e o carrega (snapshot + replay do log)
+func (rl *RemoteList) RestoreEnd(args RestoreEndArgs, reply *RestoreEndReply) error {
+	rl.backupMu.Lock()
+	s, ok := rl.restores[args.ID]
+	delete(rl.restores, args.ID)
+	rl.backupMu.Unlock()
+	if !ok {
+		return fmt.Errorf("restore %d desconhecido ou expirado", args.ID)
+	}
+
+	snap, entries, err := decodeBackup(s.data)
+	if err != nil {
+		return err
+	}
+	state, err := Replay(snap, entries)
+	if err != nil {
+		return err
+	}
+	err = rl.replaceState(state.Lists, func() error {
+		//confere de novo, agora com os handlers parados
+		if len(rl.lists) > 0 {
+			return errNotEmpty
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	reply.LSN = state.LSN
+	reply.Lists = len(state.Lists)
+	return nil
+}
+
+func (rl *RemoteList) hasLists() bool {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return len(rl.lists) > 0
+}
+
+// decodeBackup separa e valida o snapshot e as entradas de log de um backup
+func decodeBackup(data []byte) (*Snapshot, []LogEntry, error) {
+	format, hdr, err := detectFormat(data, backupMagic)
+	if err != nil {
+		return nil, nil, err
+	}
+	if format != FormatBinary {
+		return nil, nil, errors.New("arquivo não é um backup")
+	}
+	n, k := binary.Uvarint(data[hdr:])
+	if k <= 0 || n > uint64(len(data)-hdr-k) {
+		return nil, nil, errors.New("backup truncado (snapshot)")
+	}
+	start := hdr + k
+	snap, err := decodeSnapshot(data[start : start+int(n)])
+	if err != nil {
+		return nil, nil, fmt.Errorf("snapshot do backup: %w", err)
+	}
+	tail := data[start+int(n):]
+	if f, _, _ := detectFormat(tail, logMagic); f != FormatBinary {
+		return nil, nil, errors.New("backup incompleto (sem o log)")
+	}
+	sd, err := readSegment(0, tail)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sd.badOffset >= 0 {
+		return nil, nil, fmt.Errorf("log do backup corrompido no registro %d: %s", sd.badRecord, sd.badReason)
+	}
+	next := snap.LSN + 1
+	for _, e := range sd.entries {
+		if e.LSN != next {
+			return nil, nil, fmt.Errorf("log do backup sem a entrada %d", next)
+		}
+		next++
+	}
+	return snap, sd.entries, nil
+}
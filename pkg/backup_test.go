@@ -0,0 +1,63 @@
+package remotelist
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// um backup cujo cliente sumiu no meio do download é descartado no próximo
+// commit, em vez de continuar guardando todas as entradas
+func TestAbandonedBackupIsDropped(t *testing.T) {
+	rl := NewRemoteListWithStorage(NewMemoryStorage())
+	var begin BackupBeginReply
+	if err := rl.BackupBegin(struct{}{}, &begin); err != nil {
+		t.Fatal(err)
+	}
+	appendValues(t, rl, 1, 1)
+	rl.backupMu.Lock()
+	if n := len(rl.backups[begin.ID].tail); n != 1 {
+		t.Fatalf("tail = %d entradas, esperado 1", n)
+	}
+	rl.backups[begin.ID].lastUsed = time.Now().Add(-backupSessionTTL - time.Second)
+	rl.backupMu.Unlock()
+
+	appendValues(t, rl, 1, 2)
+	if n := rl.backupsActive.Load(); n != 0 {
+		t.Fatalf("backupsActive = %d depois do TTL", n)
+	}
+	if err := rl.BackupChunk(BackupChunkArgs{ID: begin.ID}, &BackupChunkReply{}); err == nil {
+		t.Fatal("BackupChunk aceitou um backup expirado")
+	}
+}
+
+// cada backup guarda uma cópia do estado: passando do limite, BackupBegin recusa,
+// e terminar (ou abandonar) um backup libera a vaga
+func TestBackupSessionLimit(t *testing.T) {
+	rl := NewRemoteListWithStorage(NewMemoryStorage())
+	appendValues(t, rl, 1, 1)
+	ids := make([]uint64, backupMaxSessions)
+	for i := range ids {
+		var begin BackupBeginReply
+		if err := rl.BackupBegin(struct{}{}, &begin); err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = begin.ID
+	}
+	if err := rl.BackupBegin(struct{}{}, &BackupBeginReply{}); !errors.Is(err, errTooManyBackups) {
+		t.Fatalf("erro = %v, esperado %v", err, errTooManyBackups)
+	}
+
+	//baixa o primeiro até o fim
+	for offset, eof := int64(0), false; !eof; {
+		var chunk BackupChunkReply
+		if err := rl.BackupChunk(BackupChunkArgs{ID: ids[0], Offset: offset}, &chunk); err != nil {
+			t.Fatal(err)
+		}
+		offset += int64(len(chunk.Data))
+		eof = chunk.EOF
+	}
+	if err := rl.BackupBegin(struct{}{}, &BackupBeginReply{}); err != nil {
+		t.Fatalf("BackupBegin depois de um backup terminado: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package remotelist
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// --- AppendMany e Batch (várias operações num único registro de log) ---
+
+type AppendManyArgs struct {
+	ListID int
+	Values []int
+}
+type AppendManyReply struct {
+	Size int //tamanho da lista depois do append
+}
+
+// BatchOp é uma operação do Batch. Op é um dos nomes do log: append, append_many,
+// remove, insert, set, remove_at, push_front, pop_front, create, delete, clear ou trim
+// (push_back e pop_back valem como append e remove); os campos usados são os do RPC
+// equivalente, com Index e End fazendo o papel de Start e End no trim.
+type BatchOp struct {
+	Op     string
+	ListID int
+	Index  int
+	End    int
+	Value  int
+	Values []int
+}
+type BatchArgs struct {
+	Ops []BatchOp
+}
+type BatchReply struct {
+	Results []int //por operação: valor removido (remove/remove_at), antigo (set) ou 0
+}
+
+// AppendMany: adiciona values ao final da lista list_id com uma única entrada de log
+func (rl *RemoteList) AppendMany(args AppendManyArgs, reply *AppendManyReply) error {
+	if len(args.Values) == 0 {
+		return errNoValue
+	}
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	if err := rl.commit(LogEntry{Operation: "append_many", ListID: args.ListID, Values: args.Values}); err != nil {
+		return err
+	}
+
+	ls, _ := rl.values(args.ListID)
+	reply.Size = len(ls)
+	return nil
+}
+
+// Batch: aplica as operações em ordem, tudo ou nada. As listas envolvidas ficam
+// travadas durante todo o lote, que é validado antes (ver batchList) e gravado
+// como uma única entrada de log: numa queda, ou o lote inteiro volta no replay ou nada.
+func (rl *RemoteList) Batch(args BatchArgs, reply *BatchReply) error {
+	if len(args.Ops) == 0 {
+		reply.Results = []int{}
+		return nil
+	}
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	//travar em ordem crescente de list_id evita deadlock entre lotes concorrentes
+	seen := make(map[int]bool)
+	var ids []int
+	for _, op := range args.Ops {
+		if !seen[op.ListID] {
+			seen[op.ListID] = true
+			ids = append(ids, op.ListID)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		unlock := rl.lockList(id)
+		defer unlock()
+	}
+
+	//validação sobre visões das listas (sem copiá-las): calcula o que vai para o log
+	work := make(map[int]*batchList, len(ids))
+	rl.mu.RLock()
+	for _, id := range ids {
+		d, ok := rl.lists[id]
+		work[id] = &batchList{exists: ok, base: d.values()}
+	}
+	rl.mu.RUnlock()
+
+	entry := LogEntry{Operation: "batch", Ops: make([]LogEntry, len(args.Ops))}
+	results := make([]int, len(args.Ops))
+	for i, op := range args.Ops {
+		name := op.Op
+		if alias, ok := opAliases[name]; ok {
+			name = alias
+		}
+		e := LogEntry{Operation: name, ListID: op.ListID, Index: op.Index, Value: op.Value, Values: op.Values}
+		if op.Op == "batch" {
+			return fmt.Errorf("operação %d: batch não pode ser aninhado", i)
+		}
+		b := work[op.ListID]
+		if e.Operation == "trim" {
+			e.Index, e.End = resolveRange(b.len(), op.Index, op.End)
+		}
+		if err := checkOp(b.exists, b.len(), &e); err != nil {
+			return fmt.Errorf("operação %d (%s na lista %d): %w", i, op.Op, op.ListID, err)
+		}
+		results[i] = b.apply(&e)
+		if e.Operation == "remove" || e.Operation == "remove_at" || e.Operation == "pop_front" {
+			e.Value = results[i] //como nos RPCs avulsos, o valor removido vai para o log
+		}
+		entry.Ops[i] = e
+	}
+
+	if err := rl.commit(entry); err != nil {
+		return err
+	}
+	reply.Results = results
+	return nil
+}
+
+// --- batchList (uma lista durante a validação do Batch) ---
+// a lista vista como reverse(front) + base + back, onde base é o que resta do
+// array original, só lido: append, push_front, remove, pop_front e trim mexem
+// nas pontas, e set no meio fica em patch. O lote custa o tamanho das operações,
+// não o das listas; só insert e remove_at no meio de base fazem uma cópia.
+type batchList struct {
+	exists bool
+	front  []int       //push_front, do mais antigo ao mais recente
+	base   []int       //trecho restante da lista original (copiado só por own)
+	back   []int       //append
+	patch  map[int]int //set em base, pela posição no array original
+	off    int         //posição de base[0] no array original
+}
+
+func (b *batchList) len() int {
+	return len(b.front) + len(b.base) + len(b.back)
+}
+
+// at retorna o valor na posição i da lista
+func (b *batchList) at(i int) int {
+	if i < len(b.front) {
+		return b.front[len(b.front)-1-i]
+	}
+	i -= len(b.front)
+	if i < len(b.base) {
+		if v, ok := b.patch[b.off+i]; ok {
+			return v
+		}
+		return b.base[i]
+	}
+	return b.back[i-len(b.base)]
+}
+
+// apply aplica e (já validada por checkOp) e retorna o valor removido ou substituído
+func (b *batchList) apply(e *LogEntry) int {
+	switch e.Operation {
+	case "create", "clear":
+		*b = batchList{exists: true}
+	case "delete":
+		*b = batchList{}
+	case "append":
+		b.back = append(b.back, e.Value)
+		b.exists = true
+	case "append_many":
+		b.back = append(b.back, e.Values...)
+		b.exists = true
+	case "push_front":
+		b.front = append(b.front, e.Value)
+		b.exists = true
+	case "remove":
+		v := b.at(b.len() - 1)
+		b.dropBack(1)
+		return v
+	case "pop_front":
+		v := b.at(0)
+		b.dropFront(1)
+		return v
+	case "trim":
+		b.dropBack(b.len() - e.End)
+		b.dropFront(e.Index)
+	case "set":
+		old := b.at(e.Index)
+		b.set(e.Index, e.Value)
+		return old
+	case "insert":
+		b.own()
+		b.base = slices.Insert(b.base, e.Index, e.Value)
+		b.exists = true
+	case "remove_at":
+		old := b.at(e.Index)
+		b.own()
+		b.base = slices.Delete(b.base, e.Index, e.Index+1)
+		return old
+	}
+	return 0
+}
+
+func (b *batchList) set(i, v int) {
+	if i < len(b.front) {
+		b.front[len(b.front)-1-i] = v
+		return
+	}
+	i -= len(b.front)
+	if i < len(b.base) {
+		if b.patch == nil {
+			b.patch = make(map[int]int)
+		}
+		b.patch[b.off+i] = v
+		return
+	}
+	b.back[i-len(b.base)] = v
+}
+
+// dropFront tira os k primeiros valores
+func (b *batchList) dropFront(k int) {
+	n := min(k, len(b.front))
+	b.front = b.front[:len(b.front)-n]
+	k -= n
+	n = min(k, len(b.base))
+	b.base = b.base[n:]
+	b.off += n
+	k -= n
+	b.back = b.back[k:]
+}
+
+// dropBack tira os k últimos valores
+func (b *batchList) dropBack(k int) {
+	n := min(k, len(b.back))
+	b.back = b.back[:len(b.back)-n]
+	k -= n
+	n = min(k, len(b.base))
+	b.base = b.base[:len(b.base)-n]
+	k -= n
+	b.front = b.front[k:]
+}
+
+// own junta tudo numa cópia em base, que passa a poder ser alterada
+func (b *batchList) own() {
+	ls := make([]int, 0, b.len()+1)
+	for i := 0; i < b.len(); i++ {
+		ls = append(ls, b.at(i))
+	}
+	*b = batchList{exists: b.exists, base: ls}
+}
@@ -0,0 +1,50 @@
+package remotelist
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// um lote com uma operação inválida no fim não muda nenhuma lista nem o log,
+// mesmo depois de set, remoções e trim nas mesmas listas
+func TestBatchFailureLeavesListsUntouched(t *testing.T) {
+	storage := NewMemoryStorage()
+	rl := NewRemoteListWithStorage(storage)
+	appendValues(t, rl, 1, 1, 2, 3, 4)
+	appendValues(t, rl, 2, 5, 6)
+	before := lists(t, rl)
+	entries := len(storage.entries)
+
+	ops := []BatchOp{
+		{Op: "set", ListID: 1, Index: 1, Value: 20},
+		{Op: "pop_front", ListID: 1},
+		{Op: "push_front", ListID: 2, Value: 0},
+		{Op: "trim", ListID: 1, Index: 0, End: 1},
+		{Op: "insert", ListID: 2, Index: 1, Value: 9},
+		{Op: "remove_at", ListID: 2, Index: 10},
+	}
+	err := rl.Batch(BatchArgs{Ops: ops}, &BatchReply{})
+	if !errors.Is(err, errIndex) {
+		t.Fatalf("erro = %v, esperado %v", err, errIndex)
+	}
+	if got := lists(t, rl); !reflect.DeepEqual(got, before) {
+		t.Fatalf("listas = %v, antes %v", got, before)
+	}
+	if n := len(storage.entries); n != entries {
+		t.Fatalf("log com %d entradas, antes %d", n, entries)
+	}
+
+	//sem a última operação, o lote passa e devolve o que cada uma removeu ou trocou
+	var reply BatchReply
+	if err := rl.Batch(BatchArgs{Ops: ops[:len(ops)-1]}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 1, 0, 0, 0}; !reflect.DeepEqual(reply.Results, want) {
+		t.Fatalf("resultados = %v, esperado %v", reply.Results, want)
+	}
+	want := map[int][]int{1: {20, 3}, 2: {0, 9, 5, 6}}
+	if got := lists(t, rl); !reflect.DeepEqual(got, want) {
+		t.Fatalf("listas = %v, esperado %v", got, want)
+	}
+}
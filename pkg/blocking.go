@@ -0,0 +1,204 @@
+package remotelist
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// --- BlockingPop (espera um elemento em qualquer das listas, como o BLPOP do Redis) ---
+// Quem espera fica numa fila FIFO por lista. Quando um commit acrescenta valores
+// a uma lista com espera, o próprio commit (ainda com o lock da lista) tira o
+// primeiro valor para o waiter mais antigo e grava o pop_front no log: ninguém
+// chega na frente de quem já esperava, e nenhuma espera acontece com lock travado.
+
+type BlockingPopArgs struct {
+	ListIDs []int         //verificadas nesta ordem
+	Timeout time.Duration //0 = espera indefinidamente
+}
+type BlockingPopReply struct {
+	ListID   int
+	Value    int
+	TimedOut bool //nenhum valor chegou dentro do Timeout
+}
+
+var errShutdown = errors.New("servidor encerrando")
+
+type popResult struct {
+	listID int
+	value  int
+	err    error
+}
+
+type popWaiter struct {
+	lists []int
+	ch    chan popResult //buffer 1: quem atende nunca bloqueia
+	done  bool           //atendido, cancelado ou encerrado (protegido por waitMu)
+}
+
+// BlockingPop: remove e retorna o primeiro elemento da primeira lista não vazia
+// de ListIDs; se todas estiverem vazias, espera até Timeout
+func (rl *RemoteList) BlockingPop(args BlockingPopArgs, reply *BlockingPopReply) error {
+	if len(args.ListIDs) == 0 {
+		return errors.New("nenhuma lista informada")
+	}
+	w, err := rl.popOrWait(args.ListIDs, reply)
+	if w == nil {
+		return err
+	}
+
+	//sem nenhum lock travado daqui em diante
+	var timeout <-chan time.Time
+	if args.Timeout > 0 {
+		t := time.NewTimer(args.Timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	var res popResult
+	select {
+	case res = <-w.ch:
+	case <-timeout:
+		if rl.cancelWaiter(w) {
+			reply.TimedOut = true
+			return nil
+		}
+		//foi atendido enquanto o timer disparava
+		res = <-w.ch
+	}
+	if res.err != nil {
+		return res.err
+	}
+	reply.ListID = res.listID
+	reply.Value = res.value
+	return nil
+}
+
+// popOrWait tenta o pop imediato; se todas as listas estão vazias, registra o
+// waiter antes de soltar os locks das listas (nenhum valor novo passa despercebido)
+func (rl *RemoteList) popOrWait(ids []int, reply *BlockingPopReply) (*popWaiter, error) {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+	for _, id := range sorted {
+		unlock := rl.lockList(id)
+		defer unlock()
+	}
+
+	for _, id := range ids {
+		ls, _ := rl.values(id)
+		if len(ls) == 0 {
+			continue
+		}
+		val := ls[0]
+		if err := rl.commit(LogEntry{Operation: "pop_front", ListID: id, Value: val}); err != nil {
+			return nil, err
+		}
+		reply.ListID = id
+		reply.Value = val
+		return nil, nil
+	}
+
+	rl.waitMu.Lock()
+	defer rl.waitMu.Unlock()
+	if rl.closing {
+		return nil, errShutdown
+	}
+	w := &popWaiter{lists: sorted, ch: make(chan popResult, 1)}
+	for _, id := range sorted {
+		rl.waiters[id] = append(rl.waiters[id], w)
+	}
+	rl.waiting.Add(1)
+	return w, nil
+}
+
+// cancelWaiter tira w das filas; false se ele já tinha sido atendido
+func (rl *RemoteList) cancelWaiter(w *popWaiter) bool {
+	rl.waitMu.Lock()
+	defer rl.waitMu.Unlock()
+	if w.done {
+		return false
+	}
+	rl.removeWaiterLocked(w)
+	return true
+}
+
+// removeWaiterLocked marca w como encerrado e o tira de todas as filas. Assume waitMu travado.
+func (rl *RemoteList) removeWaiterLocked(w *popWaiter) {
+	w.done = true
+	rl.waiting.Add(-1)
+	for _, id := range w.lists {
+		q := rl.waiters[id]
+		for i, x := range q {
+			if x == w {
+				q = append(q[:i:i], q[i+1:]...)
+				break
+			}
+		}
+		if len(q) == 0 {
+			delete(rl.waiters, id)
+		} else {
+			rl.waiters[id] = q
+		}
+	}
+}
+
+// serveWaiters entrega valores de id aos waiters mais antigos enquanto houver
+// valores e waiters. Chamado por commit, com o lock da lista travado.
+func (rl *RemoteList) serveWaiters(id int) {
+	for {
+		ls, _ := rl.values(id)
+		if len(ls) == 0 {
+			return
+		}
+		rl.waitMu.Lock()
+		q := rl.waiters[id]
+		if len(q) == 0 {
+			rl.waitMu.Unlock()
+			return
+		}
+		w := q[0]
+		rl.removeWaiterLocked(w)
+		rl.waitMu.Unlock()
+
+		val := ls[0]
+		err := rl.commit(LogEntry{Operation: "pop_front", ListID: id, Value: val})
+		w.ch <- popResult{listID: id, value: val, err: err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// shutdownWaiters acorda todos os waiters com errShutdown e recusa novos
+func (rl *RemoteList) shutdownWaiters() {
+	rl.waitMu.Lock()
+	defer rl.waitMu.Unlock()
+	rl.closing = true
+	for _, q := range rl.waiters {
+		for _, w := range q {
+			if !w.done {
+				rl.removeWaiterLocked(w)
+				w.ch <- popResult{err: errShutdown}
+			}
+		}
+	}
+}
+
+// addedLists retorna as listas em que a entrada pode ter acrescentado valores
+func addedLists(e *LogEntry) []int {
+	switch e.Operation {
+	case "append", "append_many", "push_front", "insert":
+		return []int{e.ListID}
+	case "batch":
+		var ids []int
+		for i := range e.Ops {
+			ids = append(ids, addedLists(&e.Ops[i])...)
+		}
+		slices.Sort(ids)
+		return slices.Compact(ids)
+	}
+	return nil
+}
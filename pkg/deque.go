@@ -0,0 +1,156 @@
+package remotelist
+
+// --- deque (representação de cada lista em memória) ---
+// os valores ficam contíguos em buf[head:], com espaço livre antes de head:
+// PushFront ocupa esse espaço e PopFront só avança head, ambos O(1) amortizado.
+// Por serem contíguos, values() serve direto para snapshots e leituras.
+//
+// Cada alteração mantém também a soma, o mínimo e o máximo (ver Aggregate).
+// Remover um valor igual ao mínimo ou ao máximo invalida os dois, que só são
+// recalculados quando alguém pede (refreshExtrema).
+type deque struct {
+	buf  []int
+	head int
+
+	sum       int
+	min, max  int
+	extremaOK bool //min e max valem (com a lista vazia, tanto faz)
+}
+
+// espaço mínimo reservado na frente quando PushFront precisa realocar
+const dequeMinFront = 8
+
+// newDeque cria uma lista existente (values nil vira lista vazia)
+func newDeque(values []int) deque {
+	if values == nil {
+		values = []int{}
+	}
+	d := deque{buf: values}
+	for _, v := range values {
+		d.sum += v
+	}
+	d.refreshExtrema()
+	return d
+}
+
+// values devolve os valores sem copiar (nil se a lista não existe)
+func (d deque) values() []int {
+	return d.buf[d.head:]
+}
+
+func (d deque) len() int {
+	return len(d.buf) - d.head
+}
+
+// clone copia os valores para um array novo (nunca nil, mesmo vazio), com folga
+// no final para que o append seguinte não precise copiar tudo de novo
+func (d deque) clone() deque {
+	n := d.len()
+	d.buf, d.head = append(make([]int, 0, n+max(n/4, dequeMinFront)), d.values()...), 0
+	return d
+}
+
+func (d deque) pushBack(vs ...int) deque {
+	for _, v := range vs {
+		d.added(v)
+		d.buf = append(d.buf, v)
+	}
+	return d
+}
+
+func (d deque) popBack() deque {
+	d.removed(d.buf[len(d.buf)-1])
+	d.buf = d.buf[:len(d.buf)-1]
+	return d
+}
+
+func (d deque) pushFront(v int) deque {
+	d.added(v)
+	if d.head == 0 {
+		//realoca com tanto espaço na frente quanto há valores: dobra como o append
+		n := d.len()
+		front := max(n, dequeMinFront)
+		buf := make([]int, front+n, front+n+max(n/2, dequeMinFront))
+		copy(buf[front:], d.values())
+		d.buf, d.head = buf, front
+	}
+	d.head--
+	d.buf[d.head] = v
+	return d
+}
+
+func (d deque) popFront() deque {
+	d.removed(d.buf[d.head])
+	d.head++
+	return d.reclaim()
+}
+
+// set troca o valor da posição i
+func (d deque) set(i, v int) deque {
+	d.removed(d.buf[d.head+i])
+	d.added(v)
+	d.buf[d.head+i] = v
+	return d
+}
+
+// trim mantém só values()[from:to], sem copiar
+func (d deque) trim(from, to int) deque {
+	ls := d.values()
+	for _, v := range ls[:from] {
+		d.removed(v)
+	}
+	for _, v := range ls[to:] {
+		d.removed(v)
+	}
+	d.buf = d.buf[:d.head+to]
+	d.head += from
+	return d.reclaim()
+}
+
+// reclaim devolve o espaço que ficou na frente depois de remoções pelo início
+func (d deque) reclaim() deque {
+	switch {
+	case d.head == len(d.buf):
+		d.buf, d.head = d.buf[:0], 0
+	case d.head > 1024 && d.head > len(d.buf)/2:
+		//mais da metade do array já saiu pela frente: compacta para liberar memória
+		d = d.clone()
+	}
+	return d
+}
+
+// added atualiza os agregados com v, antes de v entrar na lista
+func (d *deque) added(v int) {
+	d.sum += v
+	switch {
+	case d.len() == 0:
+		d.min, d.max, d.extremaOK = v, v, true
+	case d.extremaOK:
+		d.min = min(d.min, v)
+		d.max = max(d.max, v)
+	}
+}
+
+// removed atualiza os agregados sem v, que está saindo da lista
+func (d *deque) removed(v int) {
+	d.sum -= v
+	if v == d.min || v == d.max {
+		d.extremaOK = false
+	}
+}
+
+// refreshExtrema recalcula min e max percorrendo a lista, se preciso (O(n))
+func (d *deque) refreshExtrema() {
+	if d.extremaOK {
+		return
+	}
+	ls := d.values()
+	if len(ls) > 0 {
+		d.min, d.max = ls[0], ls[0]
+		for _, v := range ls[1:] {
+			d.min = min(d.min, v)
+			d.max = max(d.max, v)
+		}
+	}
+	d.extremaOK = true
+}
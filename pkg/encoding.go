@@ -0,0 +1,427 @@
+package remotelist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// --- formatos de log e snapshot ---
+type Format int
+
+const (
+	FormatJSON   Format = iota //log JSONL com crc/tamanho, snapshot em JSON indentado (padrão)
+	FormatBinary               //binário versionado, com inteiros em varint
+)
+
+func (f Format) String() string {
+	if f == FormatBinary {
+		return "binary"
+	}
+	return "json"
+}
+
+// ParseFormat converte o nome usado na linha de comando ("json", "binary")
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "json", "":
+		return FormatJSON, nil
+	case "binary":
+		return FormatBinary, nil
+	}
+	return FormatJSON, fmt.Errorf("formato desconhecido: %q", s)
+}
+
+// arquivos binários começam com magic + versão; sem o magic o arquivo é JSON
+const binaryVersion = 1
+
+var (
+	logMagic  = []byte("RLOG")
+	snapMagic = []byte("RSNP")
+)
+
+func binaryHeader(magic []byte) []byte {
+	return append(append([]byte(nil), magic...), binaryVersion)
+}
+
+// detectFormat olha o cabeçalho de data e retorna o formato e o tamanho do cabeçalho
+func detectFormat(data, magic []byte) (Format, int, error) {
+	if len(data) <= len(magic) || !bytes.HasPrefix(data, magic) {
+		return FormatJSON, 0, nil
+	}
+	if v := data[len(magic)]; v != binaryVersion {
+		return FormatBinary, 0, fmt.Errorf("versão binária %d não suportada", v)
+	}
+	return FormatBinary, len(magic) + 1, nil
+}
+
+// --- registro do log em JSONL ---
+// cada linha é "<crc32 em hex> <tamanho> <json>\n", com CRC (IEEE) e tamanho
+// calculados sobre o JSON. Linhas que começam com '{' são do formato antigo, sem checksum.
+func encodeRecord(payload []byte) []byte {
+	rec := fmt.Appendf(nil, "%08x %d ", crc32.ChecksumIEEE(payload), len(payload))
+	rec = append(rec, payload...)
+	return append(rec, '\n')
+}
+
+// decodeRecord valida e decodifica uma linha do log (sem o '\n')
+func decodeRecord(line []byte) (LogEntry, error) {
+	var entry LogEntry
+	payload := line
+	if len(line) == 0 || line[0] != '{' {
+		parts := bytes.SplitN(line, []byte{' '}, 3)
+		if len(parts) != 3 {
+			return entry, errors.New("registro sem cabeçalho crc/tamanho")
+		}
+		crc, err := strconv.ParseUint(string(parts[0]), 16, 32)
+		if err != nil {
+			return entry, fmt.Errorf("crc inválido: %q", parts[0])
+		}
+		n, err := strconv.Atoi(string(parts[1]))
+		if err != nil {
+			return entry, fmt.Errorf("tamanho inválido: %q", parts[1])
+		}
+		payload = parts[2]
+		if len(payload) != n {
+			return entry, fmt.Errorf("tamanho não confere (esperado %d, lido %d)", n, len(payload))
+		}
+		if crc32.ChecksumIEEE(payload) != uint32(crc) {
+			return entry, errors.New("checksum não confere")
+		}
+	}
+	if err := json.Unmarshal(payload, &entry); err != nil {
+		return entry, fmt.Errorf("json inválido: %w", err)
+	}
+	return entry, nil
+}
+
+// decodeTextAt decodifica a linha que começa em off. entry nil sem erro = linha vazia.
+// next aponta para a linha seguinte mesmo quando há erro.
+func decodeTextAt(data []byte, off int) (*LogEntry, int, error) {
+	end := bytes.IndexByte(data[off:], '\n')
+	if end < 0 {
+		if _, err := decodeRecord(data[off:]); err != nil {
+			return nil, len(data), err
+		}
+		//o '\n' faz parte do registro: sem ele a escrita não terminou
+		return nil, len(data), errors.New("registro incompleto (sem fim de linha)")
+	}
+	end += off
+	if end == off {
+		return nil, end + 1, nil
+	}
+	entry, err := decodeRecord(data[off:end])
+	if err != nil {
+		return nil, end + 1, err
+	}
+	return &entry, end + 1, nil
+}
+
+// --- registro do log em binário ---
+// uvarint(tamanho) | crc32 (IEEE, little endian) | payload
+// payload: uvarint(lsn) varint(timestamp) opcode varint(list_id) e campos
+// opcionais (tag + varint), omitidos quando valem zero.
+var opCodes = map[string]byte{
+	"append":      1,
+	"remove":      2,
+	"insert":      3,
+	"set":         4,
+	"remove_at":   5,
+	"append_many": 6,
+	"batch":       7,
+	"push_front":  8,
+	"pop_front":   9,
+	"create":      10,
+	"delete":      11,
+	"clear":       12,
+	"trim":        13,
+	"restore":     14,
+}
+
+const (
+	tagValue  = 1
+	tagIndex  = 2
+	tagValues = 3 //uvarint(n) + n varints
+	tagOps    = 4 //uvarint(n) + n vezes uvarint(tamanho) payload (com lsn e timestamp zerados)
+	tagEnd    = 5
+)
+
+func opName(code byte) (string, bool) {
+	for name, c := range opCodes {
+		if c == code {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func encodeEntryBinary(e *LogEntry) ([]byte, error) {
+	p, err := encodeEntryPayload(e)
+	if err != nil {
+		return nil, err
+	}
+	rec := binary.AppendUvarint(nil, uint64(len(p)))
+	rec = binary.LittleEndian.AppendUint32(rec, crc32.ChecksumIEEE(p))
+	return append(rec, p...), nil
+}
+
+func encodeEntryPayload(e *LogEntry) ([]byte, error) {
+	code, ok := opCodes[e.Operation]
+	if !ok {
+		return nil, fmt.Errorf("operação sem código binário: %q", e.Operation)
+	}
+	p := binary.AppendUvarint(nil, e.LSN)
+	p = binary.AppendVarint(p, e.Timestamp)
+	p = append(p, code)
+	p = binary.AppendVarint(p, int64(e.ListID))
+	if e.Value != 0 {
+		p = append(p, tagValue)
+		p = binary.AppendVarint(p, int64(e.Value))
+	}
+	if e.Index != 0 {
+		p = append(p, tagIndex)
+		p = binary.AppendVarint(p, int64(e.Index))
+	}
+	if e.End != 0 {
+		p = append(p, tagEnd)
+		p = binary.AppendVarint(p, int64(e.End))
+	}
+	if len(e.Values) > 0 {
+		p = append(p, tagValues)
+		p = binary.AppendUvarint(p, uint64(len(e.Values)))
+		for _, v := range e.Values {
+			p = binary.AppendVarint(p, int64(v))
+		}
+	}
+	if len(e.Ops) > 0 {
+		p = append(p, tagOps)
+		p = binary.AppendUvarint(p, uint64(len(e.Ops)))
+		for i := range e.Ops {
+			sub, err := encodeEntryPayload(&e.Ops[i])
+			if err != nil {
+				return nil, err
+			}
+			p = binary.AppendUvarint(p, uint64(len(sub)))
+			p = append(p, sub...)
+		}
+	}
+	return p, nil
+}
+
+// decodeBinaryAt decodifica o registro binário que começa em off
+func decodeBinaryAt(data []byte, off int) (*LogEntry, int, error) {
+	n, k := binary.Uvarint(data[off:])
+	if k <= 0 {
+		return nil, len(data), errors.New("registro incompleto (tamanho)")
+	}
+	start := off + k + 4
+	if start > len(data) || n > uint64(len(data)-start) {
+		return nil, len(data), fmt.Errorf("registro incompleto (tamanho %d além do fim do arquivo)", n)
+	}
+	end := start + int(n)
+	p := data[start:end]
+	if crc32.ChecksumIEEE(p) != binary.LittleEndian.Uint32(data[off+k:start]) {
+		return nil, end, errors.New("checksum não confere")
+	}
+	entry, err := decodeEntryPayload(p)
+	if err != nil {
+		return nil, end, err
+	}
+	return entry, end, nil
+}
+
+func decodeEntryPayload(p []byte) (*LogEntry, error) {
+	r := &varintReader{buf: p}
+	var e LogEntry
+	e.LSN = r.uvarint()
+	e.Timestamp = r.varint()
+	code := r.byte()
+	e.ListID = int(r.varint())
+	for r.err == nil && r.off < len(r.buf) {
+		switch tag := r.byte(); tag {
+		case tagValue:
+			e.Value = int(r.varint())
+		case tagIndex:
+			e.Index = int(r.varint())
+		case tagEnd:
+			e.End = int(r.varint())
+		case tagValues:
+			n := r.uvarint()
+			if n > uint64(len(r.buf)) {
+				return nil, errShortBuffer
+			}
+			e.Values = make([]int, n)
+			for i := range e.Values {
+				e.Values[i] = int(r.varint())
+			}
+		case tagOps:
+			n := r.uvarint()
+			if n > uint64(len(r.buf)) {
+				return nil, errShortBuffer
+			}
+			e.Ops = make([]LogEntry, 0, n)
+			for i := uint64(0); i < n && r.err == nil; i++ {
+				sub := r.bytes(int(r.uvarint()))
+				if r.err != nil {
+					break
+				}
+				op, err := decodeEntryPayload(sub)
+				if err != nil {
+					return nil, err
+				}
+				e.Ops = append(e.Ops, *op)
+			}
+		default:
+			return nil, fmt.Errorf("campo desconhecido %d", tag)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	name, ok := opName(code)
+	if !ok {
+		return nil, fmt.Errorf("opcode desconhecido %d", code)
+	}
+	e.Operation = name
+	return &e, nil
+}
+
+// --- snapshot ---
+// binário: magic+versão | uvarint(lsn) varint(timestamp) uvarint(nº de listas)
+// e, por lista, varint(id) uvarint(tamanho) varint(valores...) | crc32 do que veio antes
+func encodeSnapshot(snap *Snapshot, format Format) ([]byte, error) {
+	if format != FormatBinary {
+		return json.MarshalIndent(snap, "", "  ")
+	}
+	ids := make([]int, 0, len(snap.Lists))
+	for id := range snap.Lists {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	b := binaryHeader(snapMagic)
+	b = binary.AppendUvarint(b, snap.LSN)
+	b = binary.AppendVarint(b, snap.Timestamp)
+	b = binary.AppendUvarint(b, uint64(len(ids)))
+	for _, id := range ids {
+		ls := snap.Lists[id]
+		b = binary.AppendVarint(b, int64(id))
+		b = binary.AppendUvarint(b, uint64(len(ls)))
+		for _, v := range ls {
+			b = binary.AppendVarint(b, int64(v))
+		}
+	}
+	return binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(b)), nil
+}
+
+// decodeSnapshot detecta o formato pelo cabeçalho
+func decodeSnapshot(data []byte) (*Snapshot, error) {
+	format, hdr, err := detectFormat(data, snapMagic)
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if format == FormatJSON {
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return nil, err
+		}
+	} else {
+		if len(data) < hdr+4 {
+			return nil, errors.New("snapshot binário truncado")
+		}
+		body := data[:len(data)-4]
+		if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+			return nil, errors.New("checksum do snapshot não confere")
+		}
+		r := &varintReader{buf: body, off: hdr}
+		snap.LSN = r.uvarint()
+		snap.Timestamp = r.varint()
+		n := r.uvarint()
+		snap.Lists = make(map[int][]int)
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			id := int(r.varint())
+			size := r.uvarint()
+			if size > uint64(len(body)) {
+				return nil, errors.New("snapshot binário inválido")
+			}
+			ls := make([]int, size)
+			for j := range ls {
+				ls[j] = int(r.varint())
+			}
+			snap.Lists[id] = ls
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+	if snap.Lists == nil {
+		snap.Lists = make(map[int][]int)
+	}
+	return &snap, nil
+}
+
+// --- varintReader (leitura sequencial que guarda o primeiro erro) ---
+type varintReader struct {
+	buf []byte
+	off int
+	err error
+}
+
+var errShortBuffer = errors.New("registro truncado")
+
+func (r *varintReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, k := binary.Uvarint(r.buf[r.off:])
+	if k <= 0 {
+		r.err = errShortBuffer
+		return 0
+	}
+	r.off += k
+	return v
+}
+
+func (r *varintReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, k := binary.Varint(r.buf[r.off:])
+	if k <= 0 {
+		r.err = errShortBuffer
+		return 0
+	}
+	r.off += k
+	return v
+}
+
+func (r *varintReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf)-r.off {
+		r.err = errShortBuffer
+		return nil
+	}
+	b := r.buf[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *varintReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.off >= len(r.buf) {
+		r.err = errShortBuffer
+		return 0
+	}
+	b := r.buf[r.off]
+	r.off++
+	return b
+}
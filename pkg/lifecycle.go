@@ -0,0 +1,92 @@
+package remotelist
+
+import "slices"
+
+// --- ciclo de vida das listas ---
+// Append e os demais ainda criam a lista no primeiro uso; CreateList e DeleteList
+// fazem isso explicitamente, cada um com sua entrada no log (create/delete).
+
+type CreateListArgs struct {
+	ListID int
+}
+type CreateListReply struct {
+	Created bool //false se a lista já existia
+}
+
+type DeleteListArgs struct {
+	ListID int
+}
+type DeleteListReply struct {
+	Size int //quantos elementos a lista tinha
+}
+
+type ExistsArgs struct {
+	ListID int
+}
+type ExistsReply struct {
+	Exists bool
+}
+
+// CreateList: cria a lista list_id vazia; se ela já existe, nada muda (e nada vai para o log)
+func (rl *RemoteList) CreateList(args CreateListArgs, reply *CreateListReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	if _, ok := rl.values(args.ListID); ok {
+		return nil
+	}
+	if err := rl.commit(LogEntry{Operation: "create", ListID: args.ListID}); err != nil {
+		return err
+	}
+	reply.Created = true
+	return nil
+}
+
+// DeleteList: apaga a lista list_id com todos os elementos
+func (rl *RemoteList) DeleteList(args DeleteListArgs, reply *DeleteListReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	//o lock da lista é liberado de listLocks quando o último a usá-lo destravar
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, ok := rl.values(args.ListID)
+	if !ok {
+		return errNoList
+	}
+	size := len(ls)
+	if err := rl.commit(LogEntry{Operation: "delete", ListID: args.ListID}); err != nil {
+		return err
+	}
+	reply.Size = size
+	return nil
+}
+
+// Exists: diz se a lista list_id existe (mesmo vazia)
+func (rl *RemoteList) Exists(args ExistsArgs, reply *ExistsReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	_, reply.Exists = rl.values(args.ListID)
+	return nil
+}
+
+// ListIDs: retorna os list_id existentes, em ordem crescente
+func (rl *RemoteList) ListIDs(_ struct{}, reply *[]int) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	rl.mu.RLock()
+	ids := make([]int, 0, len(rl.lists))
+	for id := range rl.lists {
+		ids = append(ids, id)
+	}
+	rl.mu.RUnlock()
+	slices.Sort(ids)
+	*reply = ids
+	return nil
+}
@@ -0,0 +1,57 @@
+package remotelist
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// --- migração do formato antigo (remotelist/: uma única lista em lista_dados.json) ---
+
+// MigrateLegacy importa o arquivo do servidor antigo (um []int em JSON) para a
+// lista listID, grava um snapshot novo e renomeia o arquivo para path.migrated.
+// Retorna false se não há arquivo a migrar. Deve ser chamado na inicialização,
+// depois de LoadFromSnapshot e antes de atender clientes.
+func (rl *RemoteList) MigrateLegacy(path string, listID int) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var items []int
+	if err := json.Unmarshal(data, &items); err != nil {
+		return false, fmt.Errorf("%s não está no formato antigo ([]int em JSON): %w", path, err)
+	}
+	if items == nil {
+		items = []int{}
+	}
+
+	rl.mu.RLock()
+	d, exists := rl.lists[listID]
+	cur := d.values()
+	lists := make(map[int][]int, len(rl.lists)+1)
+	for k, d := range rl.lists {
+		lists[k] = d.values()
+	}
+	rl.mu.RUnlock()
+
+	switch {
+	case exists && slices.Equal(cur, items):
+		//já importado (queda entre o snapshot e o rename): só falta marcar o arquivo
+	case exists && len(cur) > 0:
+		return false, fmt.Errorf("lista %d já existe com %d valores; escolha outro list_id para importar %s", listID, len(cur), path)
+	default:
+		lists[listID] = items
+		if err := rl.replaceState(lists, nil); err != nil {
+			return false, err
+		}
+	}
+	//o snapshot já está no disco: o arquivo antigo não é mais lido
+	if err := os.Rename(path, path+".migrated"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
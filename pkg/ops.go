@@ -0,0 +1,122 @@
+package remotelist
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// --- operações sobre uma lista ---
+// checkOp e applyOp definem o efeito de cada operação do log num só lugar:
+// o replay (applyLogEntry) e a validação do Batch usam as mesmas regras.
+
+var (
+	errNoList  = errors.New("lista não existe")
+	errEmpty   = errors.New("lista vazia ou não existente")
+	errIndex   = errors.New("índice fora do intervalo")
+	errNoValue = errors.New("nenhum valor informado")
+	errExists  = errors.New("lista já existe")
+)
+
+// opAliases são nomes aceitos no Batch para operações do log
+var opAliases = map[string]string{
+	"push_back": "append",
+	"pop_back":  "remove",
+}
+
+// checkOp diz se e pode ser aplicada a uma lista com n valores (exists = false:
+// lista inexistente, n == 0)
+func checkOp(exists bool, n int, e *LogEntry) error {
+	switch e.Operation {
+	case "append", "push_front":
+		return nil
+	case "create":
+		if exists {
+			return errExists
+		}
+		return nil
+	case "delete", "clear":
+		if !exists {
+			return errNoList
+		}
+		return nil
+	case "trim":
+		if !exists {
+			return errNoList
+		}
+		if e.Index < 0 || e.Index > e.End || e.End > n {
+			return errIndex
+		}
+		return nil
+	case "append_many":
+		if len(e.Values) == 0 {
+			return errNoValue
+		}
+		return nil
+	case "remove", "pop_front":
+		if n == 0 {
+			return errEmpty
+		}
+		return nil
+	case "insert":
+		if e.Index < 0 || e.Index > n {
+			return errIndex
+		}
+		return nil
+	case "set", "remove_at":
+		if !exists {
+			return errNoList
+		}
+		if e.Index < 0 || e.Index >= n {
+			return errIndex
+		}
+		return nil
+	}
+	return fmt.Errorf("operação desconhecida: %q", e.Operation)
+}
+
+// applyOp aplica e a d, que pode ser alterado, e devolve a lista resultante.
+// Assume checkOp(..., d.len(), e) == nil. delete não passa por aqui: quem aplica
+// tira a lista do mapa.
+func applyOp(d deque, e *LogEntry) deque {
+	switch e.Operation {
+	case "create", "clear":
+		return newDeque(nil)
+	case "trim":
+		return d.trim(e.Index, e.End)
+	case "append":
+		return d.pushBack(e.Value)
+	case "append_many":
+		return d.pushBack(e.Values...)
+	case "remove":
+		return d.popBack()
+	case "push_front":
+		return d.pushFront(e.Value)
+	case "pop_front":
+		return d.popFront()
+	case "insert":
+		return newDeque(slices.Insert(d.values(), e.Index, e.Value))
+	case "set":
+		return d.set(e.Index, e.Value)
+	case "remove_at":
+		return newDeque(slices.Delete(d.values(), e.Index, e.Index+1))
+	}
+	return d
+}
+
+// resolveRange converte start e end no estilo LRANGE (inclusivos, negativos
+// contam do fim) no trecho [from, to) de uma lista de tamanho n; vazio se from == to
+func resolveRange(n, start, end int) (from, to int) {
+	if start < 0 {
+		start += n
+	}
+	if end < 0 {
+		end += n
+	}
+	from = max(start, 0)
+	to = min(end+1, n)
+	if from >= to {
+		return 0, 0
+	}
+	return from, to
+}
@@ -0,0 +1,131 @@
+package remotelist
+
+import (
+	"fmt"
+	"time"
+)
+
+// --- política de durabilidade do log ---
+type SyncMode int
+
+const (
+	SyncOS       SyncMode = iota //o sistema operacional decide quando gravar no disco (padrão)
+	SyncAlways                   //fsync a cada entrada, antes de responder ao cliente
+	SyncInterval                 //fsync periódico; perde no máximo um intervalo numa queda de energia
+)
+
+func (m SyncMode) String() string {
+	switch m {
+	case SyncAlways:
+		return "always"
+	case SyncInterval:
+		return "interval"
+	default:
+		return "os"
+	}
+}
+
+// ParseSyncMode converte o nome usado na linha de comando ("always", "interval", "os")
+func ParseSyncMode(s string) (SyncMode, error) {
+	switch s {
+	case "always":
+		return SyncAlways, nil
+	case "interval":
+		return SyncInterval, nil
+	case "os", "":
+		return SyncOS, nil
+	}
+	return SyncOS, fmt.Errorf("modo de sync desconhecido: %q", s)
+}
+
+// --- opções do NewRemoteListWithBase ---
+type Option func(*options)
+
+type options struct {
+	syncMode      SyncMode
+	syncInterval  time.Duration
+	repair        bool
+	ignoreBadSnap bool
+	format        Format
+	snapshotKeep  int
+	snapshotGzip  bool
+	segmentSize   int64
+	maxPageSize   int
+}
+
+func defaultOptions() options {
+	return options{
+		syncMode:     SyncOS,
+		syncInterval: 100 * time.Millisecond,
+		snapshotKeep: 1,
+		segmentSize:  64 << 20,
+		maxPageSize:  1000,
+	}
+}
+
+// WithSync define quando o log é sincronizado com o disco. interval só é usado
+// em SyncInterval; valores <= 0 mantêm o padrão (100ms).
+func WithSync(mode SyncMode, interval time.Duration) Option {
+	return func(o *options) {
+		o.syncMode = mode
+		if interval > 0 {
+			o.syncInterval = interval
+		}
+	}
+}
+
+// WithRepair permite carregar um log corrompido no meio: o segmento é truncado
+// no primeiro registro inválido e o restante vai para arquivos *.corrupt.
+// Sem ela, LoadFromSnapshot retorna *CorruptionError e não aplica o log.
+func WithRepair(repair bool) Option {
+	return func(o *options) {
+		o.repair = repair
+	}
+}
+
+// WithIgnoreBadSnapshot permite subir quando o snapshot atual existe mas não pode
+// ser lido: o arquivo vai para *.corrupt e só o log é aplicado. O que estava só no
+// snapshot (o log que ele cobria pode já ter sido apagado) é perdido. Sem ela,
+// LoadFromSnapshot retorna *SnapshotError.
+func WithIgnoreBadSnapshot(ignore bool) Option {
+	return func(o *options) {
+		o.ignoreBadSnap = ignore
+	}
+}
+
+// WithFormat escolhe o formato de novos snapshots e segmentos de log. Arquivos
+// existentes continuam legíveis: o formato é detectado pelo cabeçalho.
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// WithSnapshotHistory mantém os últimos keep snapshots (o atual mais keep-1
+// arquivados com o timestamp no nome); gzip comprime os novos snapshots.
+func WithSnapshotHistory(keep int, gzip bool) Option {
+	return func(o *options) {
+		if keep < 1 {
+			keep = 1
+		}
+		o.snapshotKeep = keep
+		o.snapshotGzip = gzip
+	}
+}
+
+// WithSegmentSize define o tamanho a partir do qual o segmento de log ativo é
+// fechado e um novo é aberto (0 = só troca de segmento nos snapshots)
+func WithSegmentSize(bytes int64) Option {
+	return func(o *options) {
+		o.segmentSize = bytes
+	}
+}
+
+// WithMaxPageSize limita quantos elementos GetRange devolve por chamada (padrão 1000)
+func WithMaxPageSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxPageSize = n
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package remotelist
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// --- recuperação point-in-time ---
+// reconstrói o estado de todas as listas num ponto do passado: parte do último
+// snapshot (atual ou do histórico) anterior ao ponto e reaplica o log até ele.
+// Uma entrada "restore" não pode ser reaplicada: o estado depois dela vem do
+// snapshot gravado com o mesmo LSN.
+// Só lê os arquivos, então pode rodar com o servidor parado (ou antes de servir).
+
+// RecoveryTarget é o ponto a recuperar; campos zerados não limitam.
+// Com os dois preenchidos vale o que vier primeiro.
+type RecoveryTarget struct {
+	LSN  uint64    //última entrada de log incluída
+	Time time.Time //entradas com timestamp até este instante
+}
+
+func (t RecoveryTarget) includes(lsn uint64, ts int64) bool {
+	if t.LSN > 0 && lsn > t.LSN {
+		return false
+	}
+	if !t.Time.IsZero() && ts > t.Time.UnixNano() {
+		return false
+	}
+	return true
+}
+
+func (t RecoveryTarget) String() string {
+	switch {
+	case t.LSN > 0 && !t.Time.IsZero():
+		return fmt.Sprintf("lsn=%d, %s", t.LSN, t.Time.Format(time.RFC3339Nano))
+	case t.LSN > 0:
+		return fmt.Sprintf("lsn=%d", t.LSN)
+	case !t.Time.IsZero():
+		return t.Time.Format(time.RFC3339Nano)
+	}
+	return "fim do log"
+}
+
+// RecoverState devolve o estado de basePath no ponto target, como um Snapshot
+// com o LSN e o timestamp da última entrada aplicada.
+func RecoverState(basePath string, target RecoveryTarget) (*Snapshot, error) {
+	//base: o snapshot mais recente que ainda está antes do ponto
+	paths, err := ListSnapshots(basePath)
+	if err != nil {
+		return nil, err
+	}
+	base := &Snapshot{Lists: make(map[int][]int)}
+	basePathUsed := "(nenhum, estado vazio)"
+	for _, p := range paths {
+		snap, err := ReadSnapshotFile(p)
+		if err != nil {
+			fmt.Printf("[Recover] %s ignorado: %v\n", p, err)
+			continue
+		}
+		if !target.includes(snap.LSN, snap.Timestamp) || snap.LSN < base.LSN {
+			continue
+		}
+		base = snap
+		basePathUsed = p
+	}
+
+	//log: entradas posteriores à base, em ordem, até sair do ponto
+	segs, err := ReadLog(basePath)
+	if err != nil {
+		return nil, err
+	}
+	var replay []LogEntry
+	next := base.LSN + 1
+	done := false
+	for i, seg := range segs {
+		for _, e := range seg.Entries {
+			if e.LSN == 0 {
+				//log legado sem LSN: só resta comparar pelo timestamp
+				if e.Timestamp <= base.Timestamp {
+					continue
+				}
+			} else {
+				if e.LSN <= base.LSN {
+					continue
+				}
+				if e.LSN != next {
+					return nil, fmt.Errorf("log não cobre o ponto pedido: falta a entrada %d depois de %s (mantenha mais snapshots com o histórico)", next, basePathUsed)
+				}
+				next++
+			}
+			if !target.includes(e.LSN, e.Timestamp) {
+				done = true
+				break
+			}
+			if e.Operation == "restore" {
+				//o estado depois de uma restauração só existe no snapshot gravado com ela
+				return nil, fmt.Errorf("lsn %d é uma restauração e o snapshot dela não está mais no histórico (base %s)", e.LSN, basePathUsed)
+			}
+			replay = append(replay, e)
+		}
+		if done {
+			break
+		}
+		//cauda rasgada no último segmento é normal; no resto, é corrupção
+		if c := seg.Corruption; c != nil && (i < len(segs)-1 || c.ValidAfter > 0) {
+			return nil, c
+		}
+	}
+
+	snap, err := Replay(base, replay)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("[Recover] ponto %s: base %s (lsn=%d), %d entradas reaplicadas, estado em lsn=%d\n",
+		target, basePathUsed, base.LSN, len(replay), snap.LSN)
+	return snap, nil
+}
+
+// Replay aplica entries sobre base (que não é alterado) pelo mesmo caminho da
+// carga do servidor e devolve o resultado, com o LSN e o timestamp da última entrada
+func Replay(base *Snapshot, entries []LogEntry) (*Snapshot, error) {
+	ms := NewMemoryStorage()
+	if base != nil {
+		ms.snap = base.clone()
+	}
+	ms.entries = entries
+	rl := NewRemoteListWithStorage(ms)
+	if err := rl.LoadFromSnapshot(); err != nil {
+		return nil, err
+	}
+	snap := rl.captureSnapshot()
+	snap.Timestamp = 0
+	if base != nil {
+		snap.Timestamp = base.Timestamp
+	}
+	if n := len(entries); n > 0 {
+		snap.Timestamp = entries[n-1].Timestamp
+	}
+	return snap, nil
+}
+
+// --- leitura do log sem abrir o storage ---
+
+// LogSegment é um segmento de log lido do disco
+type LogSegment struct {
+	Path       string
+	Format     Format
+	Bytes      int64
+	Entries    []LogEntry       //entradas válidas, até o primeiro registro inválido
+	Corruption *CorruptionError //nil se o segmento está íntegro
+}
+
+// ReadLog lê e verifica todos os segmentos de basePath, em ordem, sem alterar nada
+func ReadLog(basePath string) ([]LogSegment, error) {
+	fs := &FileStorage{logFile: basePath + ".log"}
+	seqs, err := fs.listSegments()
+	if err != nil {
+		return nil, err
+	}
+	segs := make([]LogSegment, 0, len(seqs))
+	for _, seq := range seqs {
+		path := fs.segmentPath(seq)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sd, err := readSegment(seq, b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		format, _, _ := detectFormat(b, logMagic)
+		seg := LogSegment{Path: path, Format: format, Bytes: int64(len(b)), Entries: sd.entries}
+		if sd.badOffset >= 0 {
+			seg.Corruption = &CorruptionError{Segment: path, Record: sd.badRecord, Offset: sd.badOffset, Reason: sd.badReason, ValidAfter: sd.validAfter}
+		}
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
@@ -0,0 +1,75 @@
+package remotelist
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Restore não pode perder o estado que substitui: ele vira snapshot e, com
+// histórico, fica arquivado mesmo que ainda não houvesse snapshot nenhum
+func TestRestoreArchivesCurrentState(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "d")
+	rl, _ := openFileList(t, base, WithSnapshotHistory(2, false))
+	defer rl.Close()
+	appendValues(t, rl, 1, 1, 2)
+	before := lists(t, rl)
+
+	if err := rl.Restore(&Snapshot{Lists: map[int][]int{9: {9}}}); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := ListSnapshots(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("snapshots = %v, esperado o arquivado e o atual", paths)
+	}
+	archived, err := ReadSnapshotFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(archived.Lists, before) {
+		t.Fatalf("arquivado = %v, estado antes do Restore = %v", archived.Lists, before)
+	}
+}
+
+// a restauração ganha um LSN próprio: antes dele a recuperação devolve o estado
+// substituído, a partir dele o restaurado, e o log depois continua valendo
+func TestRecoverAroundRestore(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "d")
+	rl, _ := openFileList(t, base, WithSnapshotHistory(3, false))
+	appendValues(t, rl, 1, 1, 2, 3)
+	if err := rl.Restore(&Snapshot{Lists: map[int][]int{9: {9}}}); err != nil {
+		t.Fatal(err)
+	}
+	appendValues(t, rl, 9, 10)
+	want := lists(t, rl)
+	if err := rl.Batch(BatchArgs{Ops: []BatchOp{{Op: "restore"}}}, &BatchReply{}); err == nil {
+		t.Fatal("Batch aceitou restore")
+	}
+	if err := rl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[uint64]map[int][]int{
+		3: {1: {1, 2, 3}},
+		4: {9: {9}},
+		5: {9: {9, 10}},
+	}
+	for lsn, expected := range cases {
+		snap, err := RecoverState(base, RecoveryTarget{LSN: lsn})
+		if err != nil {
+			t.Fatalf("lsn %d: %v", lsn, err)
+		}
+		if !reflect.DeepEqual(snap.Lists, expected) {
+			t.Fatalf("lsn %d: estado = %v, esperado %v", lsn, snap.Lists, expected)
+		}
+	}
+
+	reloaded, _ := openFileList(t, base, WithSnapshotHistory(3, false))
+	defer reloaded.Close()
+	if got := lists(t, reloaded); !reflect.DeepEqual(got, want) {
+		t.Fatalf("restart = %v, antes = %v", got, want)
+	}
+}
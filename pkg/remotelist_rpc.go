@@ -1,12 +1,10 @@
 package remotelist
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -41,201 +39,449 @@ type SizeReply struct {
 	Size int
 }
 
+type PushFrontArgs struct {
+	ListID int
+	Value  int
+}
+type PushFrontReply struct {
+	OK bool
+}
+
+type PopFrontArgs struct {
+	ListID int
+}
+type PopFrontReply struct {
+	Value int
+}
+
+// GetRange segue o LRANGE do Redis: Start e End inclusivos, negativos contam do
+// fim (-1 = último), fora do intervalo são ajustados. Se o trecho passar do limite
+// da página, More vem true e a próxima página começa em Next (mesmo End).
+type GetRangeArgs struct {
+	ListID int
+	Start  int
+	End    int
+	Limit  int //tamanho da página; 0 ou acima do máximo do servidor = máximo
+}
+type GetRangeReply struct {
+	Values []int
+	Size   int //tamanho atual da lista
+	More   bool
+	Next   int //índice (absoluto) onde continuar, quando More
+}
+
+type InsertArgs struct {
+	ListID int
+	Index  int //0..Size; Size equivale a Append
+	Value  int
+}
+type InsertReply struct {
+	OK bool
+}
+
+type SetArgs struct {
+	ListID int
+	Index  int
+	Value  int
+}
+type SetReply struct {
+	Old int //valor substituído
+}
+
+type RemoveAtArgs struct {
+	ListID int
+	Index  int
+}
+type RemoveAtReply struct {
+	Value int
+}
+
+type ClearArgs struct {
+	ListID int
+}
+type ClearReply struct {
+	Removed int
+}
+
+// Trim segue o LTRIM do Redis: mantém só os elementos de Start a End (inclusivos,
+// com os mesmos índices negativos de GetRange); trecho vazio deixa a lista vazia.
+type TrimArgs struct {
+	ListID int
+	Start  int
+	End    int
+}
+type TrimReply struct {
+	Removed int
+	Size    int //tamanho depois do trim
+}
+
 // --- persistência: log entry e snapshot ---
 type LogEntry struct {
-	Timestamp int64  `json:"timestamp"`
-	Operation string `json:"operation"` //append ou remove
-	ListID    int    `json:"list_id"`
-	Value     int    `json:"value"` //para append -> valor; para remove -> valor removido
+	LSN       uint64     `json:"lsn"` //número de sequência do log (0 em entradas legadas)
+	Timestamp int64      `json:"timestamp"`
+	Operation string     `json:"operation"` //append, append_many, remove, push_front, pop_front, insert, set, remove_at, create, delete, clear, trim, batch ou restore
+	ListID    int        `json:"list_id"`
+	Value     int        `json:"value"`            //append/insert/set -> valor gravado; remove/remove_at -> valor removido
+	Index     int        `json:"index,omitempty"`  //posição em insert, set e remove_at; início do trecho mantido em trim
+	End       int        `json:"end,omitempty"`    //trim: fim (exclusivo) do trecho mantido
+	Values    []int      `json:"values,omitempty"` //append_many
+	Ops       []LogEntry `json:"ops,omitempty"`    //batch: operações aplicadas juntas (sem LSN/timestamp próprios)
 }
 
 type Snapshot struct {
+	LSN       uint64        `json:"lsn"` //última entrada do log incorporada ao snapshot
 	Timestamp int64         `json:"timestamp"`
 	Lists     map[int][]int `json:"lists"`
 }
 
 // --- RemoteList ---
 type RemoteList struct {
-	mu    sync.RWMutex //protege acesso a lists
-	lists map[int][]int
+	mu      sync.RWMutex //protege acesso a lists
+	lists   map[int]deque
+	lastLSN uint64 //LSN da última entrada aplicada (protegido por mu)
+	snapLSN uint64 //LSN do último snapshot gravado (protegido por mu)
+
+	//copy-on-write: cada snapshot capturado incrementa snapGen; uma lista cujo
+	//owned[id] != snapGen ainda divide o array com um snapshot (protegidos por mu)
+	snapGen uint64
+	owned   map[int]uint64
 
 	//locks por lista
 	locksMu   sync.Mutex
-	listLocks map[int]*sync.Mutex
+	listLocks map[int]*listLock
 
 	//snapshot vs handlers
-	snapshotRW sync.RWMutex
-
-	// rquivos
-	basePath      string
-	logFile       string
-	snapshotFile  string
-	logMutex      sync.Mutex
+	snapshotRW    sync.RWMutex
 	snapshotMutex sync.Mutex
+
+	//backup/restore em andamento (ver backup.go)
+	backupMu      sync.Mutex
+	backups       map[uint64]*backupSession
+	restores      map[uint64]*restoreSession
+	nextSession   uint64
+	backupsActive atomic.Int32 //len(backups), lido sem lock em commit
+
+	//BlockingPop: filas de espera por lista (ver blocking.go)
+	waitMu  sync.Mutex
+	waiters map[int][]*popWaiter
+	waiting atomic.Int32 //waiters registrados, lido sem lock em commit
+	closing bool         //(protegido por waitMu)
+
+	//persistência (log + snapshot)
+	storage Storage
+	opts    options
 }
 
 // --- Configuração de arquivos de persistência ---
-func NewRemoteListWithBase(basePath string) *RemoteList {
+func NewRemoteListWithBase(basePath string, opts ...Option) *RemoteList {
+	return NewRemoteListWithStorage(NewFileStorage(basePath, opts...), opts...)
+}
+
+// NewRemoteListWithStorage usa um Storage qualquer (ex.: NewMemoryStorage nos testes)
+func NewRemoteListWithStorage(storage Storage, opts ...Option) *RemoteList {
 	rl := &RemoteList{
-		lists:        make(map[int][]int),
-		listLocks:    make(map[int]*sync.Mutex),
-		basePath:     basePath,
-		logFile:      basePath + ".log",
-		snapshotFile: basePath + ".snapshot",
+		lists:     make(map[int]deque),
+		owned:     make(map[int]uint64),
+		listLocks: make(map[int]*listLock),
+		backups:   make(map[uint64]*backupSession),
+		restores:  make(map[uint64]*restoreSession),
+		waiters:   make(map[int][]*popWaiter),
+		storage:   storage,
+		opts:      defaultOptions(),
+	}
+	for _, opt := range opts {
+		opt(&rl.opts)
 	}
 	return rl
 }
 
-// --- utilitário JSONL scanner (simples) ---
-type JSONLScanner struct {
-	data  []byte
-	start int
+// --- Close (esvazia o log e fecha o storage; chamar no encerramento do servidor) ---
+func (rl *RemoteList) Close() error {
+	rl.shutdownWaiters()
+	return rl.storage.Close()
 }
 
-func NewJSONLScanner(data []byte) *JSONLScanner {
-	return &JSONLScanner{data: data}
-}
-func (s *JSONLScanner) Scan() bool {
-	for s.start < len(s.data) && s.data[s.start] == '\n' {
-		s.start++
-	}
-	return s.start < len(s.data)
-}
-func (s *JSONLScanner) Bytes() []byte {
-	end := s.start
-	for end < len(s.data) && s.data[end] != '\n' {
-		end++
-	}
-	result := s.data[s.start:end]
-	s.start = end + 1
-	return result
+// --- lock por lista ---
+// cada entrada de listLocks conta quem a está usando; quando a última sai e a
+// lista não existe (apagada, ou nunca criada num Get/Size), a entrada é liberada.
+type listLock struct {
+	sync.Mutex
+	refs int //(protegido por locksMu)
 }
 
-// --- obtém (ou cria) mutex por lista ---
-func (rl *RemoteList) getListLock(listID int) *sync.Mutex {
+// lockList trava a lista listID e devolve a função que a destrava
+func (rl *RemoteList) lockList(listID int) (unlock func()) {
 	rl.locksMu.Lock()
 	l, ok := rl.listLocks[listID]
 	if !ok {
-		l = &sync.Mutex{}
+		l = &listLock{}
 		rl.listLocks[listID] = l
 	}
+	l.refs++
 	rl.locksMu.Unlock()
-	return l
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		rl.locksMu.Lock()
+		defer rl.locksMu.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			rl.mu.RLock()
+			_, exists := rl.lists[listID]
+			rl.mu.RUnlock()
+			if !exists {
+				delete(rl.listLocks, listID)
+			}
+		}
+	}
 }
 
-// --- appendToLog (thread-safe) ---
-func (rl *RemoteList) appendToLog(op string, listID int, value int) error {
-	rl.logMutex.Lock()
-	defer rl.logMutex.Unlock()
+// --- CreateSnapshot (gera snapshot atômico e compacta o log) ---
+// os handlers só ficam parados durante captureSnapshot; a serialização e a
+// gravação acontecem com eles rodando.
+func (rl *RemoteList) CreateSnapshot() error {
+	rl.snapshotMutex.Lock()
+	defer rl.snapshotMutex.Unlock()
+
+	snap := rl.captureSnapshot()
+	if err := rl.storage.WriteSnapshot(snap); err != nil {
+		return err
+	}
+	rl.mu.Lock()
+	rl.snapLSN = snap.LSN
+	rl.mu.Unlock()
+	return nil
+}
 
-	entry := LogEntry{
+// captureSnapshot tira uma visão consistente das listas sem copiar os valores:
+// o snapshot fica com os arrays atuais e quem alterar uma lista depois faz a
+// cópia antes (ver mutableLocked).
+func (rl *RemoteList) captureSnapshot() *Snapshot {
+	//com snapshotRW travado nenhum handler está ativo, então todo o log até
+	//lastLSN já está aplicado em lists
+	rl.snapshotRW.Lock()
+	defer rl.snapshotRW.Unlock()
+	return rl.captureLocked()
+}
+
+// captureLocked é o captureSnapshot para quem já tem snapshotRW travado
+func (rl *RemoteList) captureLocked() *Snapshot {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	lists := make(map[int][]int, len(rl.lists))
+	for k, d := range rl.lists {
+		lists[k] = d.values()
+	}
+	rl.snapGen++
+	return &Snapshot{
+		LSN:       rl.lastLSN,
 		Timestamp: time.Now().UnixNano(),
-		Operation: op,
-		ListID:    listID,
-		Value:     value,
+		Lists:     lists,
 	}
-	data, err := json.Marshal(entry)
-	if err != nil {
-		return err
+}
+
+// ownLists copia, antes do apply, as listas de entry que ainda dividem o array
+// com um snapshot. A cópia (O(n)) acontece só com o lock da lista, que o chamador
+// de commit já tem, e rl.mu fica travado só para instalar o resultado: depois
+// de um snapshot, a primeira escrita numa lista grande não para as outras.
+func (rl *RemoteList) ownLists(entry *LogEntry) {
+	for _, id := range entryLists(entry) {
+		rl.mu.RLock()
+		d, ok := rl.lists[id]
+		shared := ok && rl.owned[id] != rl.snapGen
+		gen := rl.snapGen
+		rl.mu.RUnlock()
+		if !shared {
+			continue
+		}
+		//com snapshotRW.RLock (commit) nenhum snapshot muda snapGen até o apply
+		c := d.clone()
+		rl.mu.Lock()
+		if rl.snapGen == gen {
+			rl.lists[id] = c
+			rl.owned[id] = gen
+		}
+		rl.mu.Unlock()
 	}
+}
 
-	// abrir, append, fechar
-	f, err := os.OpenFile(rl.logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
+// entryLists retorna as listas que entry altera com os valores atuais (create,
+// clear e delete descartam o conteúdo, então não entram)
+func entryLists(e *LogEntry) []int {
+	switch e.Operation {
+	case "create", "clear", "delete", "restore":
+		return nil
+	case "batch":
+		var ids []int
+		for i := range e.Ops {
+			ids = append(ids, entryLists(&e.Ops[i])...)
+		}
+		slices.Sort(ids)
+		return slices.Compact(ids)
 	}
-	_, err = f.Write(append(data, '\n'))
-	_ = f.Close()
-	return err
+	return []int{e.ListID}
 }
 
-// --- CreateSnapshot (gera snapshot atômico) ---
-func (rl *RemoteList) CreateSnapshot() error {
+// mutableLocked devolve a lista id pronta para ser alterada, copiando o array
+// se ele ainda é compartilhado com um snapshot (no replay; em commit, ownLists
+// já copiou). Assume rl.mu travado.
+func (rl *RemoteList) mutableLocked(id int) deque {
+	d := rl.lists[id]
+	if rl.owned[id] != rl.snapGen {
+		d = d.clone()
+		rl.lists[id] = d
+		rl.owned[id] = rl.snapGen
+	}
+	return d
+}
+
+// values devolve os valores da lista id, sem cópia, e se ela existe. O slice
+// só pode ser lido enquanto o lock da lista estiver travado.
+func (rl *RemoteList) values(id int) ([]int, bool) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	d, ok := rl.lists[id]
+	return d.values(), ok
+}
+
+// --- LoadFromSnapshot (snapshot + replay log) ---
+func (rl *RemoteList) LoadFromSnapshot() error {
+	// no arranque, garantimos que nenhuma goroutine handler está ativa ainda,
+	// mas por segurança vamos bloquear snapshotRW enquanto carregamos.
 	rl.snapshotRW.Lock()
 	defer rl.snapshotRW.Unlock()
 
 	rl.snapshotMutex.Lock()
 	defer rl.snapshotMutex.Unlock()
 
-	rl.mu.RLock()
-	copyLists := make(map[int][]int, len(rl.lists))
-	for k, v := range rl.lists {
-		c := make([]int, len(v))
-		copy(c, v)
-		copyLists[k] = c
+	snap, entries, err := rl.storage.LoadState()
+	if err != nil {
+		return err
 	}
-	rl.mu.RUnlock()
 
-	snap := Snapshot{
-		Timestamp: time.Now().UnixNano(),
-		Lists:     copyLists,
+	rl.mu.Lock()
+	rl.lists = make(map[int]deque)
+	rl.owned = make(map[int]uint64)
+	rl.snapGen = 0
+	rl.lastLSN = 0
+	rl.snapLSN = 0
+	if snap != nil {
+		for k, v := range snap.Lists {
+			rl.lists[k] = newDeque(v)
+		}
+		rl.lastLSN = snap.LSN
+		rl.snapLSN = snap.LSN
 	}
-	data, err := json.MarshalIndent(snap, "", "  ")
-	if err != nil {
-		return err
+	rl.mu.Unlock()
+
+	for _, entry := range entries {
+		rl.applyLogEntry(entry, false /* já está persistido no log */)
 	}
+	return nil
+}
 
-	dir := filepath.Dir(rl.snapshotFile)
-	tmpFile := filepath.Join(dir, fmt.Sprintf(".%s.tmp", filepath.Base(rl.snapshotFile)))
-	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+// --- RestoreSnapshot (volta ao estado de um snapshot do histórico) ---
+// o conteúdo de path vira o snapshot atual, então a restauração sobrevive a um
+// restart. O estado substituído vai para o histórico (ver replaceState).
+func (rl *RemoteList) RestoreSnapshot(path string) error {
+	snap, err := ReadSnapshotFile(path)
+	if err != nil {
 		return err
 	}
-	return os.Rename(tmpFile, rl.snapshotFile)
+	return rl.Restore(snap)
 }
 
-// --- LoadFromSnapshot (snapshot + replay log) ---
-func (rl *RemoteList) LoadFromSnapshot() error {
-	// no arranque, garantimos que nenhuma goroutine handler está ativa ainda,
-	// mas por segurança vamos bloquear snapshotRW enquanto carregamos.
-	rl.snapshotRW.Lock()
-	defer rl.snapshotRW.Unlock()
+// Restore troca o estado atual pelas listas de snap (ex.: vindo de RecoverState)
+func (rl *RemoteList) Restore(snap *Snapshot) error {
+	return rl.replaceState(snap.Lists, nil)
+}
 
+// replaceState troca todas as listas por lists, gravando antes um snapshot com elas.
+// check (opcional) roda com os handlers parados; se falhar, nada muda.
+//
+// A troca ocupa um LSN próprio: uma entrada "restore" vai para o log e o snapshot
+// novo é gravado com o LSN dela. Assim cada LSN corresponde a um único estado
+// (o anterior fica no snapshot de LSN-1) e quem reaplica o log sabe que não dá
+// para passar do marcador sem o snapshot dele (ver RecoverState).
+func (rl *RemoteList) replaceState(lists map[int][]int, check func() error) error {
 	rl.snapshotMutex.Lock()
 	defer rl.snapshotMutex.Unlock()
+	rl.snapshotRW.Lock()
+	defer rl.snapshotRW.Unlock()
 
-	var snapTS int64 = 0
-
-	//carregar snapshot se existir
-	if b, err := os.ReadFile(rl.snapshotFile); err == nil {
-		var snap Snapshot
-		if err := json.Unmarshal(b, &snap); err == nil {
-			rl.mu.Lock()
-			rl.lists = make(map[int][]int, len(snap.Lists))
-			for k, v := range snap.Lists {
-				c := make([]int, len(v))
-				copy(c, v)
-				rl.lists[k] = c
-			}
-			rl.mu.Unlock()
-			snapTS = snap.Timestamp
-			fmt.Printf("[Load] Snapshot carregado (timestamp=%d), %d listas.\n", snap.Timestamp, len(snap.Lists))
-		} else {
-			fmt.Println("[Load] Erro ao unmarshal snapshot:", err)
+	if check != nil {
+		if err := check(); err != nil {
+			return err
 		}
-	} else {
-		fmt.Println("[Load] Nenhum snapshot encontrado, iniciando com mapa vazio")
-	}
-
-	//replay do log (aplica apenas operações posteriores ao snapshot)
-	if b, err := os.ReadFile(rl.logFile); err == nil {
-		scanner := NewJSONLScanner(b)
-		for scanner.Scan() {
-			var entry LogEntry
-			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
-				//ignorar entradas corrompidas
-				continue
-			}
-			//pular entradas já incorporadas no snapshot
-			if snapTS != 0 && entry.Timestamp <= snapTS {
-				continue
-			}
-			rl.applyLogEntry(entry, false /* já está persistido no log */)
+	}
+	//o estado atual vira o snapshot atual antes de ser substituído: o WriteSnapshot
+	//seguinte o arquiva, e com histórico (WithSnapshotHistory) ele continua recuperável
+	rl.mu.RLock()
+	lsn, pending := rl.lastLSN, rl.lastLSN != rl.snapLSN
+	rl.mu.RUnlock()
+	if pending {
+		if err := rl.storage.WriteSnapshot(rl.captureLocked()); err != nil {
+			return err
+		}
+		rl.mu.Lock()
+		rl.snapLSN = lsn
+		rl.mu.Unlock()
+	}
+	marker := LogEntry{Operation: "restore"}
+	if err := rl.storage.AppendRecord(&marker); err != nil {
+		return fmt.Errorf("erro ao gravar log de restore: %w", err)
+	}
+	//numa queda antes do snapshot, o marcador sozinho não muda nada no replay
+	rl.applyLogEntry(marker, true)
+	lsn = marker.LSN
+
+	state := make(map[int][]int, len(lists))
+	for k, v := range lists {
+		state[k] = v
+	}
+	if err := rl.storage.WriteSnapshot(&Snapshot{LSN: lsn, Timestamp: marker.Timestamp, Lists: state}); err != nil {
+		return err
+	}
+	//backups em andamento descrevem o estado anterior e não passariam do marcador
+	rl.backupMu.Lock()
+	for id, s := range rl.backups {
+		if !s.sealed {
+			rl.dropBackupLocked(id)
 		}
-		fmt.Println("[Load] Replay do log concluído")
-	} else {
+	}
+	rl.backupMu.Unlock()
 
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	//os arrays são compartilhados com o snapshot gravado: nova geração força a cópia
+	rl.lists = make(map[int]deque, len(state))
+	for k, v := range state {
+		rl.lists[k] = newDeque(v)
 	}
+	rl.snapGen++
+	rl.owned = make(map[int]uint64)
+	rl.snapLSN = lsn
+	return nil
+}
 
+// --- commit (único caminho de escrita: log primeiro, memória depois) ---
+// todo RPC que altera uma lista monta a LogEntry e passa por aqui, com
+// snapshotRW.RLock e o lock da lista já obtidos. Se o log falhar, a memória não muda.
+func (rl *RemoteList) commit(entry LogEntry) error {
+	if err := rl.storage.AppendRecord(&entry); err != nil {
+		return fmt.Errorf("erro ao gravar log de %s: %w", entry.Operation, err)
+	}
+	rl.ownLists(&entry)
+	rl.applyLogEntry(entry, true)
+	if rl.backupsActive.Load() > 0 {
+		rl.tapBackups(entry)
+	}
+	if rl.waiting.Load() > 0 {
+		for _, id := range addedLists(&entry) {
+			rl.serveWaiters(id)
+		}
+	}
 	return nil
 }
 
@@ -244,17 +490,44 @@ func (rl *RemoteList) applyLogEntry(entry LogEntry, logWritten bool) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
+	if entry.LSN > rl.lastLSN {
+		rl.lastLSN = entry.LSN
+	}
 	switch entry.Operation {
-	case "append":
-		rl.lists[entry.ListID] = append(rl.lists[entry.ListID], entry.Value)
-	case "remove":
-		if ls, ok := rl.lists[entry.ListID]; ok && len(ls) > 0 {
-			rl.lists[entry.ListID] = ls[:len(ls)-1]
+	case "batch":
+		for i := range entry.Ops {
+			rl.applyOpLocked(&entry.Ops[i])
 		}
+	case "restore":
+		//só ocupa o LSN: o estado restaurado está no snapshot gravado com ele
+	default:
+		rl.applyOpLocked(&entry)
 	}
 	_ = logWritten
 }
 
+// applyOpLocked aplica uma operação (ver ops.go); inválida no estado atual, é
+// ignorada, como um remove de lista vazia no replay. Assume rl.mu travado.
+func (rl *RemoteList) applyOpLocked(e *LogEntry) {
+	cur, exists := rl.lists[e.ListID]
+	if checkOp(exists, cur.len(), e) != nil {
+		return
+	}
+	var d deque
+	switch e.Operation {
+	case "delete":
+		delete(rl.lists, e.ListID)
+		delete(rl.owned, e.ListID)
+		return
+	case "create", "clear":
+		//a lista é substituída por uma nova: não há o que copiar
+		rl.owned[e.ListID] = rl.snapGen
+	default:
+		d = rl.mutableLocked(e.ListID)
+	}
+	rl.lists[e.ListID] = applyOp(d, e)
+}
+
 // --- RPC Methods (exported) ---
 
 // Append: adiciona value ao final da lista list_id
@@ -264,20 +537,14 @@ func (rl *RemoteList) Append(args AppendArgs, reply *AppendReply) error {
 	defer rl.snapshotRW.RUnlock()
 
 	//lock específico da lista
-	lck := rl.getListLock(args.ListID)
-	lck.Lock()
-	defer lck.Unlock()
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
 
-	//gravar no log primeiro (WAL-like) para durabilidade
-	if err := rl.appendToLog("append", args.ListID, args.Value); err != nil {
+	//gravar no log primeiro (WAL-like) para durabilidade, depois aplicar em memória
+	if err := rl.commit(LogEntry{Operation: "append", ListID: args.ListID, Value: args.Value}); err != nil {
 		return err
 	}
 
-	//aplicar em memória
-	rl.mu.Lock()
-	rl.lists[args.ListID] = append(rl.lists[args.ListID], args.Value)
-	rl.mu.Unlock()
-
 	reply.OK = true
 	return nil
 }
@@ -287,65 +554,243 @@ func (rl *RemoteList) Get(args GetArgs, reply *GetReply) error {
 	rl.snapshotRW.RLock()
 	defer rl.snapshotRW.RUnlock()
 
-	lck := rl.getListLock(args.ListID)
-	lck.Lock()
-	defer lck.Unlock()
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
 
-	rl.mu.RLock()
-	ls, ok := rl.lists[args.ListID]
-	rl.mu.RUnlock()
+	ls, ok := rl.values(args.ListID)
 	if !ok {
-		return errors.New("lista não existe")
+		return errNoList
 	}
 	if args.Index < 0 || args.Index >= len(ls) {
-		return errors.New("índice fora do intervalo")
+		return errIndex
 	}
 	reply.Value = ls[args.Index]
 	return nil
 }
 
+// GetRange: retorna os elementos de start a end (inclusive) da lista list_id, paginados
+func (rl *RemoteList) GetRange(args GetRangeArgs, reply *GetRangeReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, _ := rl.values(args.ListID)
+	from, to := resolveRange(len(ls), args.Start, args.End)
+	reply.Size = len(ls)
+
+	limit := rl.opts.maxPageSize
+	if args.Limit > 0 && args.Limit < limit {
+		limit = args.Limit
+	}
+	if to-from > limit {
+		to = from + limit
+		reply.More = true
+		reply.Next = to
+	}
+	//cópia: o array da lista muda (ou é compartilhado com um snapshot) depois que o lock é solto
+	reply.Values = append([]int{}, ls[from:to]...)
+	return nil
+}
+
 // Remove: remove e retorna o último elemento da lista list_id
 func (rl *RemoteList) Remove(args RemoveArgs, reply *RemoveReply) error {
 	rl.snapshotRW.RLock()
 	defer rl.snapshotRW.RUnlock()
 
-	lck := rl.getListLock(args.ListID)
-	lck.Lock()
-	defer lck.Unlock()
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
 
-	rl.mu.Lock()
-	ls, ok := rl.lists[args.ListID]
+	ls, ok := rl.values(args.ListID)
 	if !ok || len(ls) == 0 {
-		rl.mu.Unlock()
-		return errors.New("lista vazia ou não existente")
+		return errEmpty
 	}
 	val := ls[len(ls)-1]
-	rl.lists[args.ListID] = ls[:len(ls)-1]
-	rl.mu.Unlock()
 
-	//gravar no log o valor removido (durability)
-	if err := rl.appendToLog("remove", args.ListID, val); err != nil {
-		//se falhar ao logar, isso é grave — mas já removemos em memória.
-		//olítica: reportar erro para o cliente (pode decidir re-tentar)
-		return fmt.Errorf("erro ao gravar log de remove: %w", err)
+	//gravar no log o valor removido antes de tirar da memória; com o lock da
+	//lista travado ninguém mais muda o último elemento entre o log e o apply
+	if err := rl.commit(LogEntry{Operation: "remove", ListID: args.ListID, Value: val}); err != nil {
+		return err
+	}
+
+	reply.Value = val
+	return nil
+}
+
+// PushFront: adiciona value no início da lista list_id (O(1) amortizado)
+func (rl *RemoteList) PushFront(args PushFrontArgs, reply *PushFrontReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	if err := rl.commit(LogEntry{Operation: "push_front", ListID: args.ListID, Value: args.Value}); err != nil {
+		return err
+	}
+	reply.OK = true
+	return nil
+}
+
+// PopFront: remove e retorna o primeiro elemento da lista list_id (O(1))
+func (rl *RemoteList) PopFront(args PopFrontArgs, reply *PopFrontReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, ok := rl.values(args.ListID)
+	if !ok || len(ls) == 0 {
+		return errEmpty
 	}
+	val := ls[0]
 
+	//como em Remove, o valor removido vai para o log
+	if err := rl.commit(LogEntry{Operation: "pop_front", ListID: args.ListID, Value: val}); err != nil {
+		return err
+	}
 	reply.Value = val
 	return nil
 }
 
+// PushBack: o mesmo que Append (nome simétrico a PushFront)
+func (rl *RemoteList) PushBack(args AppendArgs, reply *AppendReply) error {
+	return rl.Append(args, reply)
+}
+
+// PopBack: o mesmo que Remove (nome simétrico a PopFront)
+func (rl *RemoteList) PopBack(args RemoveArgs, reply *RemoveReply) error {
+	return rl.Remove(args, reply)
+}
+
+// Insert: insere value na posição index da lista list_id, deslocando os seguintes
+func (rl *RemoteList) Insert(args InsertArgs, reply *InsertReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, _ := rl.values(args.ListID)
+	if args.Index < 0 || args.Index > len(ls) {
+		return errIndex
+	}
+
+	if err := rl.commit(LogEntry{Operation: "insert", ListID: args.ListID, Index: args.Index, Value: args.Value}); err != nil {
+		return err
+	}
+	reply.OK = true
+	return nil
+}
+
+// Set: substitui o valor na posição index da lista list_id
+func (rl *RemoteList) Set(args SetArgs, reply *SetReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, ok := rl.values(args.ListID)
+	if !ok {
+		return errNoList
+	}
+	if args.Index < 0 || args.Index >= len(ls) {
+		return errIndex
+	}
+	old := ls[args.Index]
+
+	if err := rl.commit(LogEntry{Operation: "set", ListID: args.ListID, Index: args.Index, Value: args.Value}); err != nil {
+		return err
+	}
+	reply.Old = old
+	return nil
+}
+
+// RemoveAt: remove e retorna o elemento na posição index da lista list_id
+func (rl *RemoteList) RemoveAt(args RemoveAtArgs, reply *RemoveAtReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, ok := rl.values(args.ListID)
+	if !ok {
+		return errNoList
+	}
+	if args.Index < 0 || args.Index >= len(ls) {
+		return errIndex
+	}
+	val := ls[args.Index]
+
+	//como em Remove, o valor removido vai para o log
+	if err := rl.commit(LogEntry{Operation: "remove_at", ListID: args.ListID, Index: args.Index, Value: val}); err != nil {
+		return err
+	}
+	reply.Value = val
+	return nil
+}
+
+// Clear: remove todos os elementos da lista list_id (a lista continua existindo)
+func (rl *RemoteList) Clear(args ClearArgs, reply *ClearReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, ok := rl.values(args.ListID)
+	if !ok {
+		return errNoList
+	}
+	n := len(ls)
+	if n > 0 {
+		//uma entrada só, sem os valores: o replay parte do mesmo estado
+		if err := rl.commit(LogEntry{Operation: "clear", ListID: args.ListID}); err != nil {
+			return err
+		}
+	}
+	reply.Removed = n
+	return nil
+}
+
+// Trim: mantém só o trecho de start a end da lista list_id
+func (rl *RemoteList) Trim(args TrimArgs, reply *TrimReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, ok := rl.values(args.ListID)
+	if !ok {
+		return errNoList
+	}
+	n := len(ls)
+	from, to := resolveRange(n, args.Start, args.End)
+	if to-from < n {
+		//no log vai o trecho já resolvido (absoluto), não os índices negativos
+		if err := rl.commit(LogEntry{Operation: "trim", ListID: args.ListID, Index: from, End: to}); err != nil {
+			return err
+		}
+	}
+	reply.Removed = n - (to - from)
+	reply.Size = to - from
+	return nil
+}
+
 // Size: retorna a quantidade de elementos da lista list_id
 func (rl *RemoteList) Size(args SizeArgs, reply *SizeReply) error {
 	rl.snapshotRW.RLock()
 	defer rl.snapshotRW.RUnlock()
 
-	lck := rl.getListLock(args.ListID)
-	lck.Lock()
-	defer lck.Unlock()
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
 
-	rl.mu.RLock()
-	ls := rl.lists[args.ListID]
-	rl.mu.RUnlock()
+	ls, _ := rl.values(args.ListID)
 	reply.Size = len(ls)
 	return nil
 }
@@ -358,10 +803,8 @@ func (rl *RemoteList) GetLists(_ struct{}, reply *map[int][]int) error {
 	rl.mu.RLock()
 	defer rl.mu.RUnlock()
 	out := make(map[int][]int, len(rl.lists))
-	for k, v := range rl.lists {
-		c := make([]int, len(v))
-		copy(c, v)
-		out[k] = c
+	for k, d := range rl.lists {
+		out[k] = append([]int{}, d.values()...)
 	}
 	*reply = out
 	return nil
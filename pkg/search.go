@@ -0,0 +1,93 @@
+package remotelist
+
+import "slices"
+
+// --- busca (executada no servidor, com o lock da lista) ---
+
+type IndexOfArgs struct {
+	ListID    int
+	Value     int
+	FromIndex int //posição onde a busca começa; negativo conta do fim
+}
+type IndexOfReply struct {
+	Index int //-1 se não encontrado
+}
+
+type ContainsArgs struct {
+	ListID int
+	Value  int
+}
+type ContainsReply struct {
+	Found bool
+}
+
+type CountArgs struct {
+	ListID int
+	Value  int
+}
+type CountReply struct {
+	Count int
+}
+
+// IndexOf: retorna a primeira posição >= from_index em que value aparece na lista list_id
+func (rl *RemoteList) IndexOf(args IndexOfArgs, reply *IndexOfReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, ok := rl.values(args.ListID)
+	if !ok {
+		return errNoList
+	}
+	from := args.FromIndex
+	if from < 0 {
+		from = max(from+len(ls), 0)
+	}
+	reply.Index = -1
+	if from < len(ls) {
+		if i := slices.Index(ls[from:], args.Value); i >= 0 {
+			reply.Index = from + i
+		}
+	}
+	return nil
+}
+
+// Contains: diz se value aparece na lista list_id
+func (rl *RemoteList) Contains(args ContainsArgs, reply *ContainsReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, ok := rl.values(args.ListID)
+	if !ok {
+		return errNoList
+	}
+	reply.Found = slices.Contains(ls, args.Value)
+	return nil
+}
+
+// Count: retorna quantas vezes value aparece na lista list_id
+func (rl *RemoteList) Count(args CountArgs, reply *CountReply) error {
+	rl.snapshotRW.RLock()
+	defer rl.snapshotRW.RUnlock()
+
+	unlock := rl.lockList(args.ListID)
+	defer unlock()
+
+	ls, ok := rl.values(args.ListID)
+	if !ok {
+		return errNoList
+	}
+	n := 0
+	for _, v := range ls {
+		if v == args.Value {
+			n++
+		}
+	}
+	reply.Count = n
+	return nil
+}
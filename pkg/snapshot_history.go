@@ -0,0 +1,168 @@
+package remotelist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// --- histórico de snapshots ---
+// o snapshot atual fica sempre em lista_dados.snapshot; os anteriores viram
+// lista_dados.snapshot.<timestamp UTC>[.gz], que ordenam pelo nome.
+const snapshotTimeLayout = "20060102T150405.000000000Z"
+
+func (fs *FileStorage) historyPath(ts int64, gz bool) string {
+	p := fs.snapshotFile + "." + time.Unix(0, ts).UTC().Format(snapshotTimeLayout)
+	if gz {
+		p += ".gz"
+	}
+	return p
+}
+
+// archiveSnapshot guarda o snapshot atual no histórico (se houver histórico).
+// Usa hard link para que lista_dados.snapshot nunca deixe de existir.
+func (fs *FileStorage) archiveSnapshot() error {
+	if fs.opts.snapshotKeep <= 1 {
+		return nil
+	}
+	data, err := os.ReadFile(fs.snapshotFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fs.snapMu.Lock()
+	defer fs.snapMu.Unlock()
+	ts := fs.snapTS
+	if ts == 0 {
+		if st, err := os.Stat(fs.snapshotFile); err == nil {
+			ts = st.ModTime().UnixNano()
+		}
+	}
+	dst := fs.historyPath(ts, isGzip(data))
+	if err := os.Link(fs.snapshotFile, dst); err != nil && !os.IsExist(err) {
+		//sistema de arquivos sem hard link: copia
+		if err := writeFileSync(dst, data); err != nil {
+			return err
+		}
+	}
+	fs.histLSN[dst] = fs.snapLSN
+	return nil
+}
+
+// pruneSnapshots apaga os snapshots arquivados além da retenção configurada
+func (fs *FileStorage) pruneSnapshots() error {
+	hist, err := snapshotHistory(fs.snapshotFile)
+	if err != nil {
+		return err
+	}
+	keep := fs.opts.snapshotKeep - 1
+	for len(hist) > keep {
+		if err := os.Remove(hist[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fs.snapMu.Lock()
+		delete(fs.histLSN, hist[0])
+		fs.snapMu.Unlock()
+		hist = hist[1:]
+	}
+	return nil
+}
+
+// retainLSN devolve até que LSN o log pode ser descartado: o menor LSN entre o
+// snapshot atual (current) e os arquivados, para que todos sirvam de base de recuperação
+func (fs *FileStorage) retainLSN(current uint64) uint64 {
+	if fs.opts.snapshotKeep <= 1 {
+		return current
+	}
+	hist, err := snapshotHistory(fs.snapshotFile)
+	if err != nil {
+		return 0 //na dúvida, não descarta nada
+	}
+	fs.snapMu.Lock()
+	defer fs.snapMu.Unlock()
+	retain := current
+	for _, p := range hist {
+		lsn, ok := fs.histLSN[p]
+		if !ok {
+			//arquivado antes deste processo: lê uma vez e guarda
+			snap, err := ReadSnapshotFile(p)
+			if err != nil {
+				continue
+			}
+			lsn = snap.LSN
+			fs.histLSN[p] = lsn
+		}
+		if lsn < retain {
+			retain = lsn
+		}
+	}
+	return retain
+}
+
+// snapshotHistory lista os snapshots arquivados, do mais antigo ao mais novo
+func snapshotHistory(snapshotFile string) ([]string, error) {
+	matches, err := filepath.Glob(snapshotFile + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var hist []string
+	prefix := filepath.Base(snapshotFile) + "."
+	for _, m := range matches {
+		name := filepath.Base(m)[len(prefix):]
+		if len(name) < len(snapshotTimeLayout) {
+			continue
+		}
+		if _, err := time.Parse(snapshotTimeLayout, name[:len(snapshotTimeLayout)]); err != nil {
+			continue
+		}
+		hist = append(hist, m)
+	}
+	sort.Strings(hist)
+	return hist, nil
+}
+
+// ListSnapshots retorna os snapshots de basePath, do mais antigo ao atual
+// (lista_dados.snapshot, se existir, é sempre o último)
+func ListSnapshots(basePath string) ([]string, error) {
+	snapshotFile := basePath + ".snapshot"
+	hist, err := snapshotHistory(snapshotFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(snapshotFile); err == nil {
+		hist = append(hist, snapshotFile)
+	}
+	return hist, nil
+}
+
+// --- gzip (stdlib) ---
+func isGzip(b []byte) bool {
+	return len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipBytes(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
@@ -0,0 +1,75 @@
+package remotelist
+
+import (
+	"fmt"
+	"time"
+)
+
+// --- política de snapshots ---
+// dispara no primeiro critério atingido; campos zerados ficam desligados.
+// Nada é gravado se nenhuma entrada foi aplicada desde o último snapshot.
+type SnapshotPolicy struct {
+	EveryRecords uint64        //entradas de log desde o último snapshot
+	EveryBytes   int64         //bytes de log desde o último snapshot (só para storages que sabem medir, ex.: FileStorage)
+	Interval     time.Duration //tempo desde o último snapshot
+}
+
+// frequência com que RunSnapshotter confere os contadores
+const snapshotCheckInterval = 250 * time.Millisecond
+
+// logSizer é implementado pelos storages que sabem quanto log acumularam desde o último snapshot
+type logSizer interface {
+	PendingLogBytes() int64
+}
+
+// --- RunSnapshotter (agenda snapshots conforme a política; bloqueia até stop) ---
+func (rl *RemoteList) RunSnapshotter(policy SnapshotPolicy, stop <-chan struct{}) {
+	check := snapshotCheckInterval
+	if policy.Interval > 0 && policy.Interval < check {
+		check = policy.Interval
+	}
+	ticker := time.NewTicker(check)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		reason := rl.snapshotDue(policy, last)
+		if reason == "" {
+			continue
+		}
+		fmt.Printf("[Snapshot] iniciando (%s)...\n", reason)
+		if err := rl.CreateSnapshot(); err != nil {
+			fmt.Println("[Snapshot] erro ao criar snapshot:", err)
+			continue
+		}
+		fmt.Println("[Snapshot] snapshot criado com sucesso")
+		last = time.Now()
+	}
+}
+
+// snapshotDue diz qual critério da política foi atingido ("" = nenhum)
+func (rl *RemoteList) snapshotDue(policy SnapshotPolicy, last time.Time) string {
+	rl.mu.RLock()
+	pending := rl.lastLSN - rl.snapLSN
+	rl.mu.RUnlock()
+	if pending == 0 {
+		return ""
+	}
+	if policy.EveryRecords > 0 && pending >= policy.EveryRecords {
+		return fmt.Sprintf("%d entradas no log", pending)
+	}
+	if ls, ok := rl.storage.(logSizer); ok && policy.EveryBytes > 0 {
+		if n := ls.PendingLogBytes(); n >= policy.EveryBytes {
+			return fmt.Sprintf("%d bytes de log", n)
+		}
+	}
+	if policy.Interval > 0 && time.Since(last) >= policy.Interval {
+		return fmt.Sprintf("%s desde o último", policy.Interval)
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package remotelist
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// com histórico, o log anterior ao snapshot atual fica no disco; EveryBytes só
+// pode contar o que foi escrito depois do snapshot, senão dispara a cada checagem
+func TestEveryBytesIgnoresRetainedLog(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "d")
+	rl, fs := openFileList(t, base, WithSnapshotHistory(3, false), WithSegmentSize(256))
+	defer rl.Close()
+	policy := SnapshotPolicy{EveryBytes: 1024}
+
+	//dois snapshots: o log entre eles fica para o point-in-time a partir do primeiro
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 100; i++ {
+			appendValues(t, rl, 1, i)
+		}
+		if reason := rl.snapshotDue(policy, time.Now()); reason == "" {
+			t.Fatal("EveryBytes não disparou com o log acima do limite")
+		}
+		if err := rl.CreateSnapshot(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	appendValues(t, rl, 1, 100)
+	if reason := rl.snapshotDue(policy, time.Now()); reason != "" {
+		t.Fatalf("snapshot devido logo depois do anterior: %s", reason)
+	}
+	var retained int64
+	fs.fileMu.Lock()
+	for _, n := range fs.segBytes {
+		retained += n
+	}
+	fs.fileMu.Unlock()
+	if retained < policy.EveryBytes {
+		t.Fatalf("log mantido = %d bytes, o teste precisa de mais que %d", retained, policy.EveryBytes)
+	}
+}
@@ -0,0 +1,91 @@
+package remotelist
+
+import (
+	"sync"
+	"time"
+)
+
+// --- Storage (mecanismo de persistência por trás da RemoteList) ---
+// Os handlers RPC só falam com o Storage através de commit, CreateSnapshot e
+// LoadFromSnapshot; trocar de engine não mexe nos handlers.
+type Storage interface {
+	// AppendRecord grava a entrada no log, preenchendo LSN e Timestamp.
+	// Só retorna depois que a entrada está gravada conforme a política de durabilidade.
+	AppendRecord(entry *LogEntry) error
+	// WriteSnapshot grava snap e pode descartar o log que ele cobre (LSN <= snap.LSN)
+	WriteSnapshot(snap *Snapshot) error
+	// LoadState retorna o último snapshot (nil se não houver) e, em ordem de LSN,
+	// as entradas do log posteriores a ele
+	LoadState() (*Snapshot, []LogEntry, error)
+	Close() error
+}
+
+// --- MemoryStorage (tudo em memória, para testes) ---
+// guarda só o último snapshot e as entradas posteriores, então LoadState numa
+// nova RemoteList com o mesmo MemoryStorage simula um restart.
+type MemoryStorage struct {
+	mu      sync.Mutex
+	lastLSN uint64
+	snap    *Snapshot
+	entries []LogEntry
+	closed  bool
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{}
+}
+
+func (ms *MemoryStorage) AppendRecord(entry *LogEntry) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.closed {
+		return errLogClosed
+	}
+	ms.lastLSN++
+	entry.LSN = ms.lastLSN
+	entry.Timestamp = time.Now().UnixNano()
+	ms.entries = append(ms.entries, *entry)
+	return nil
+}
+
+func (ms *MemoryStorage) WriteSnapshot(snap *Snapshot) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.snap = snap.clone()
+	kept := ms.entries[:0]
+	for _, e := range ms.entries {
+		if e.LSN > snap.LSN {
+			kept = append(kept, e)
+		}
+	}
+	ms.entries = kept
+	return nil
+}
+
+func (ms *MemoryStorage) LoadState() (*Snapshot, []LogEntry, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var snap *Snapshot
+	if ms.snap != nil {
+		snap = ms.snap.clone()
+	}
+	entries := make([]LogEntry, len(ms.entries))
+	copy(entries, ms.entries)
+	return snap, entries, nil
+}
+
+func (ms *MemoryStorage) Close() error {
+	ms.mu.Lock()
+	ms.closed = true
+	ms.mu.Unlock()
+	return nil
+}
+
+// clone faz uma cópia profunda do snapshot
+func (s *Snapshot) clone() *Snapshot {
+	c := &Snapshot{LSN: s.LSN, Timestamp: s.Timestamp, Lists: make(map[int][]int, len(s.Lists))}
+	for k, v := range s.Lists {
+		c.Lists[k] = append([]int(nil), v...)
+	}
+	return c
+}
@@ -0,0 +1,398 @@
+package remotelist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// --- FileStorage (snapshot + log em segmentos, em JSON ou binário) ---
+type FileStorage struct {
+	basePath     string
+	logFile      string //prefixo dos segmentos de log (lista_dados.log.000001, ...)
+	snapshotFile string
+	opts         options
+
+	//log (ver wal.go): logMutex protege o enfileiramento contra o Close,
+	//fileMu protege o segmento aberto e a numeração usados pelo escritor
+	logClosed bool //(protegido por logMutex)
+	logCh     chan *logRequest
+	logDone   chan struct{}
+	logMutex  sync.Mutex
+	lastLSN   uint64         //último LSN gravado (protegido por fileMu)
+	logFailed error          //escrita que não pôde ser desfeita; recusa novas entradas (protegido por fileMu)
+	logSeg    int            //segmento de log ativo (protegido por fileMu)
+	logFD     logFile        //segmento ativo aberto (protegido por fileMu)
+	logDirty  bool           //há escrita ainda sem fsync (protegido por fileMu)
+	logFormat Format         //formato do segmento aberto (protegido por fileMu)
+	segMaxLSN map[int]uint64 //maior LSN gravado em cada segmento (protegido por fileMu)
+	segBytes  map[int]int64  //tamanho de cada segmento (protegido por fileMu)
+	fileMu    sync.Mutex
+
+	//snapshot atual e histórico (ver snapshot_history.go), protegidos por snapMu
+	snapTS  int64             //timestamp do snapshot atual, usado no nome ao arquivá-lo
+	snapLSN uint64            //LSN do snapshot atual
+	histLSN map[string]uint64 //LSN de cada snapshot arquivado já conhecido
+	snapMu  sync.Mutex
+
+	stopSync  chan struct{}
+	closeOnce sync.Once
+}
+
+func NewFileStorage(basePath string, opts ...Option) *FileStorage {
+	fs := &FileStorage{
+		basePath:     basePath,
+		logFile:      basePath + ".log",
+		snapshotFile: basePath + ".snapshot",
+		opts:         defaultOptions(),
+		logCh:        make(chan *logRequest, maxGroupCommit),
+		logDone:      make(chan struct{}),
+		logSeg:       1,
+		segMaxLSN:    make(map[int]uint64),
+		segBytes:     make(map[int]int64),
+		histLSN:      make(map[string]uint64),
+		stopSync:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&fs.opts)
+	}
+	go fs.logWriter()
+	if fs.opts.syncMode == SyncInterval {
+		go fs.syncLoop()
+	}
+	return fs
+}
+
+// --- segmentos de log ---
+
+// segmentPath retorna o caminho do segmento seq. O segmento 0 é o log legado
+// sem numeração (lista_dados.log), lido apenas na carga e apagado no próximo snapshot.
+func (fs *FileStorage) segmentPath(seq int) string {
+	if seq == 0 {
+		return fs.logFile
+	}
+	return fmt.Sprintf("%s.%06d", fs.logFile, seq)
+}
+
+// listSegments retorna os números dos segmentos existentes em ordem crescente
+func (fs *FileStorage) listSegments() ([]int, error) {
+	matches, err := filepath.Glob(fs.logFile + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var segs []int
+	if _, err := os.Stat(fs.logFile); err == nil {
+		segs = append(segs, 0)
+	}
+	prefix := fs.logFile + "."
+	for _, m := range matches {
+		seq, err := strconv.Atoi(strings.TrimPrefix(m, prefix))
+		if err != nil || seq <= 0 {
+			continue
+		}
+		segs = append(segs, seq)
+	}
+	sort.Ints(segs)
+	return segs, nil
+}
+
+// --- WriteSnapshot (snapshot atômico + compactação do log) ---
+func (fs *FileStorage) WriteSnapshot(snap *Snapshot) error {
+	data, err := encodeSnapshot(snap, fs.opts.format)
+	if err != nil {
+		return err
+	}
+	if fs.opts.snapshotGzip {
+		if data, err = gzipBytes(data); err != nil {
+			return err
+		}
+	}
+
+	dir := filepath.Dir(fs.snapshotFile)
+	tmpFile := filepath.Join(dir, fmt.Sprintf(".%s.tmp", filepath.Base(fs.snapshotFile)))
+	//o snapshot substitui segmentos do log, então precisa estar no disco antes do rename
+	if err := writeFileSync(tmpFile, data); err != nil {
+		return err
+	}
+	if err := syncDir(dir); err != nil {
+		return err
+	}
+	//o snapshot atual vai para o histórico antes de ser substituído
+	if err := fs.archiveSnapshot(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile, fs.snapshotFile); err != nil {
+		return err
+	}
+	//o rename precisa estar no disco antes de apagar qualquer segmento que o snapshot cobre:
+	//numa queda logo depois, o snapshot antigo voltaria sem o log dele
+	if err := syncDir(dir); err != nil {
+		return err
+	}
+	fs.snapMu.Lock()
+	fs.snapTS = snap.Timestamp
+	fs.snapLSN = snap.LSN
+	fs.snapMu.Unlock()
+	if err := fs.pruneSnapshots(); err != nil {
+		return err
+	}
+	//com histórico, o log é mantido desde o snapshot mais antigo (recuperação point-in-time)
+	retain := fs.retainLSN(snap.LSN)
+
+	//sela o segmento ativo e escolhe os segmentos inteiramente cobertos pelos snapshots;
+	//se cair antes de apagar, as entradas antigas continuam sendo puladas pelo LSN na carga
+	fs.fileMu.Lock()
+	if err := fs.rotateLocked(); err != nil {
+		fs.fileMu.Unlock()
+		return err
+	}
+	var covered []int
+	for seq, max := range fs.segMaxLSN {
+		if seq < fs.logSeg && max <= retain {
+			covered = append(covered, seq)
+			delete(fs.segMaxLSN, seq)
+			delete(fs.segBytes, seq)
+		}
+	}
+	fs.fileMu.Unlock()
+
+	sort.Ints(covered)
+	for _, seq := range covered {
+		if err := os.Remove(fs.segmentPath(seq)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- writeFileSync (WriteFile + fsync) ---
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir faz fsync do diretório, tornando duráveis as criações, renames e
+// remoções de arquivos feitas nele
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ReadSnapshotFile lê um snapshot do disco em qualquer formato (JSON, binário, com ou sem gzip)
+func ReadSnapshotFile(path string) (*Snapshot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isGzip(b) {
+		if b, err = gunzipBytes(b); err != nil {
+			return nil, err
+		}
+	}
+	return decodeSnapshot(b)
+}
+
+// --- LoadState (snapshot + log posterior a ele) ---
+func (fs *FileStorage) LoadState() (*Snapshot, []LogEntry, error) {
+	var snapTS int64 = 0
+	var snapLSN, lastLSN uint64
+	startGapOK := false //snapshot ilegível ignorado: o log pode começar em qualquer LSN
+
+	//carregar snapshot se existir
+	snap, err := ReadSnapshotFile(fs.snapshotFile)
+	switch {
+	case os.IsNotExist(err):
+		fmt.Println("[Load] Nenhum snapshot encontrado, iniciando com mapa vazio")
+		snap = nil
+	case err != nil:
+		if !fs.opts.ignoreBadSnap {
+			return nil, nil, &SnapshotError{Path: fs.snapshotFile, Err: err}
+		}
+		//guarda o arquivo: o próximo snapshot sobrescreveria a evidência
+		fmt.Printf("[Load] snapshot ilegível (%v), ignorado e movido para %s.corrupt\n", err, fs.snapshotFile)
+		if err := os.Rename(fs.snapshotFile, fs.snapshotFile+".corrupt"); err != nil {
+			return nil, nil, err
+		}
+		snap = nil
+		startGapOK = true
+	default:
+		fs.snapMu.Lock()
+		fs.snapTS = snap.Timestamp
+		fs.snapLSN = snap.LSN
+		fs.snapMu.Unlock()
+		snapTS = snap.Timestamp
+		snapLSN = snap.LSN
+		lastLSN = snap.LSN
+		fmt.Printf("[Load] Snapshot carregado (lsn=%d), %d listas.\n", snap.LSN, len(snap.Lists))
+	}
+
+	//replay do log, segmento por segmento (devolve apenas operações posteriores ao snapshot)
+	segs, err := fs.listSegments()
+	if err != nil {
+		return nil, nil, err
+	}
+	//primeiro lê e verifica todos os segmentos; nada é devolvido se houver corrupção
+	raws := make([][]byte, len(segs))
+	datas := make([]segmentData, len(segs))
+	for i, seq := range segs {
+		b, err := os.ReadFile(fs.segmentPath(seq))
+		if err != nil {
+			return nil, nil, err
+		}
+		raws[i] = b
+		if datas[i], err = readSegment(seq, b); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", fs.segmentPath(seq), err)
+		}
+	}
+	for i, sd := range datas {
+		if sd.badOffset < 0 {
+			continue
+		}
+		path := fs.segmentPath(sd.seq)
+		validAfter := sd.validAfter
+		for _, later := range datas[i+1:] {
+			validAfter += len(later.entries) + later.validAfter
+		}
+		if i == len(datas)-1 && validAfter == 0 {
+			//cauda rasgada por uma queda no meio da escrita: descarta o registro incompleto
+			fmt.Printf("[Load] %s: registro final incompleto (%s), truncando em %d bytes\n", path, sd.badReason, sd.validEnd)
+			if err := os.Truncate(path, int64(sd.validEnd)); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		if !fs.opts.repair {
+			return nil, nil, &CorruptionError{Segment: path, Record: sd.badRecord, Offset: sd.badOffset, Reason: sd.badReason, ValidAfter: validAfter}
+		}
+		//reparo: trunca no ponto corrompido e tira de cena os segmentos seguintes
+		fmt.Printf("[Load] reparo: %s corrompido no registro %d (%s); descartando a partir do byte %d\n", path, sd.badRecord, sd.badReason, sd.badOffset)
+		if err := quarantine(path, raws[i], sd.badOffset); err != nil {
+			return nil, nil, err
+		}
+		for _, later := range datas[i+1:] {
+			lp := fs.segmentPath(later.seq)
+			fmt.Printf("[Load] reparo: segmento %s descartado\n", lp)
+			if err := os.Rename(lp, lp+".corrupt"); err != nil {
+				return nil, nil, err
+			}
+		}
+		segs = segs[:i+1]
+		datas = datas[:i+1]
+		break
+	}
+
+	//as entradas posteriores ao snapshot precisam ser contíguas (snapLSN+1, +2, ...):
+	//um buraco (segmento apagado ou perdido) daria um estado parcial sem aviso
+	var entries []LogEntry
+	next := snapLSN + 1
+	segMaxLSN := make(map[int]uint64, len(datas))
+	segBytes := make(map[int]int64, len(datas))
+	for i, sd := range datas {
+		segMaxLSN[sd.seq] = 0
+		segBytes[sd.seq] = int64(len(raws[i]))
+		for _, entry := range sd.entries {
+			//pular entradas já incorporadas no snapshot
+			if entry.LSN == 0 {
+				//log legado sem LSN: só resta comparar pelo timestamp
+				if snapTS != 0 && entry.Timestamp <= snapTS {
+					continue
+				}
+			} else {
+				if entry.LSN > lastLSN {
+					lastLSN = entry.LSN
+				}
+				if entry.LSN > segMaxLSN[sd.seq] {
+					segMaxLSN[sd.seq] = entry.LSN
+				}
+				if entry.LSN <= snapLSN {
+					continue
+				}
+				switch {
+				case entry.LSN < next:
+					return nil, nil, fmt.Errorf("%s: lsn %d repetido ou fora de ordem (esperado %d)", fs.segmentPath(sd.seq), entry.LSN, next)
+				case entry.LSN > next && !startGapOK:
+					return nil, nil, fmt.Errorf("%s: log sem as entradas %d a %d (encontrada a %d); o estado carregado ficaria incompleto",
+						fs.segmentPath(sd.seq), next, entry.LSN-1, entry.LSN)
+				case entry.LSN > next:
+					fmt.Printf("[Load] o log começa no lsn %d: entradas %d a %d perdidas com o snapshot\n", entry.LSN, next, entry.LSN-1)
+				}
+				startGapOK = false
+				next = entry.LSN + 1
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if len(segs) > 0 {
+		fmt.Printf("[Load] Replay do log concluído (%d segmentos)\n", len(segs))
+	}
+
+	//continua escrevendo no último segmento existente
+	//e continua a sequência de LSN de onde parou
+	fs.fileMu.Lock()
+	if n := len(segs); n > 0 && segs[n-1] > 0 && segs[n-1] != fs.logSeg {
+		_ = fs.closeLogLocked()
+		fs.logSeg = segs[n-1]
+	}
+	fs.segMaxLSN = segMaxLSN
+	fs.segBytes = segBytes
+	fs.lastLSN = lastLSN
+	fs.fileMu.Unlock()
+
+	return snap, entries, nil
+}
+
+// PendingLogBytes retorna o tamanho dos segmentos de log com entradas posteriores
+// ao snapshot atual. Os segmentos mantidos só para o histórico não contam: já
+// estão cobertos por um snapshot e só somem quando ele sai do histórico.
+func (fs *FileStorage) PendingLogBytes() int64 {
+	fs.snapMu.Lock()
+	snapLSN := fs.snapLSN
+	fs.snapMu.Unlock()
+
+	fs.fileMu.Lock()
+	defer fs.fileMu.Unlock()
+	var total int64
+	for seq, n := range fs.segBytes {
+		if seq == fs.logSeg || fs.segMaxLSN[seq] > snapLSN {
+			total += n
+		}
+	}
+	return total
+}
+
+// --- SnapshotError (snapshot atual existe mas não pode ser lido) ---
+type SnapshotError struct {
+	Path string
+	Err  error
+}
+
+func (e *SnapshotError) Error() string {
+	return fmt.Sprintf("snapshot %s ilegível: %v. o log que ele cobria pode já ter sido apagado, então subir sem ele perderia dados; "+
+		"reinicie ignorando o snapshot só se aceitar essa perda (o arquivo é guardado em *.corrupt)", e.Path, e.Err)
+}
+
+func (e *SnapshotError) Unwrap() error {
+	return e.Err
+}
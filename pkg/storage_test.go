@@ -0,0 +1,116 @@
+package remotelist
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var errInjected = errors.New("falha injetada")
+
+// failingStorage é um MemoryStorage cujo AppendRecord falha enquanto fail for true
+type failingStorage struct {
+	*MemoryStorage
+	fail bool
+}
+
+func (s *failingStorage) AppendRecord(entry *LogEntry) error {
+	if s.fail {
+		return errInjected
+	}
+	return s.MemoryStorage.AppendRecord(entry)
+}
+
+func lists(t *testing.T, rl *RemoteList) map[int][]int {
+	t.Helper()
+	var all map[int][]int
+	if err := rl.GetLists(struct{}{}, &all); err != nil {
+		t.Fatal(err)
+	}
+	return all
+}
+
+// com o log falhando, nenhum RPC que altera listas pode mudar a memória, e o
+// estado em memória tem que ser o mesmo que um restart reconstrói do log
+func TestCommitFailureLeavesMemoryUnchanged(t *testing.T) {
+	ops := map[string]func(rl *RemoteList) error{
+		"Append":    func(rl *RemoteList) error { return rl.Append(AppendArgs{ListID: 1, Value: 9}, &AppendReply{}) },
+		"Remove":    func(rl *RemoteList) error { return rl.Remove(RemoveArgs{ListID: 1}, &RemoveReply{}) },
+		"PushFront": func(rl *RemoteList) error { return rl.PushFront(PushFrontArgs{ListID: 1, Value: 9}, &PushFrontReply{}) },
+		"PopFront":  func(rl *RemoteList) error { return rl.PopFront(PopFrontArgs{ListID: 1}, &PopFrontReply{}) },
+		"Insert": func(rl *RemoteList) error {
+			return rl.Insert(InsertArgs{ListID: 1, Index: 1, Value: 9}, &InsertReply{})
+		},
+		"Set":      func(rl *RemoteList) error { return rl.Set(SetArgs{ListID: 1, Index: 0, Value: 9}, &SetReply{}) },
+		"RemoveAt": func(rl *RemoteList) error { return rl.RemoveAt(RemoveAtArgs{ListID: 1, Index: 1}, &RemoveAtReply{}) },
+		"AppendMany": func(rl *RemoteList) error {
+			return rl.AppendMany(AppendManyArgs{ListID: 1, Values: []int{7, 8}}, &AppendManyReply{})
+		},
+		"CreateList": func(rl *RemoteList) error { return rl.CreateList(CreateListArgs{ListID: 5}, &CreateListReply{}) },
+		"DeleteList": func(rl *RemoteList) error { return rl.DeleteList(DeleteListArgs{ListID: 1}, &DeleteListReply{}) },
+		"Clear":      func(rl *RemoteList) error { return rl.Clear(ClearArgs{ListID: 1}, &ClearReply{}) },
+		"Trim":       func(rl *RemoteList) error { return rl.Trim(TrimArgs{ListID: 1, Start: 1, End: -1}, &TrimReply{}) },
+		"BlockingPop": func(rl *RemoteList) error {
+			return rl.BlockingPop(BlockingPopArgs{ListIDs: []int{1}}, &BlockingPopReply{})
+		},
+		"Batch": func(rl *RemoteList) error {
+			return rl.Batch(BatchArgs{Ops: []BatchOp{{Op: "append", ListID: 2, Value: 1}, {Op: "pop_front", ListID: 1}}}, &BatchReply{})
+		},
+	}
+	for name, op := range ops {
+		t.Run(name, func(t *testing.T) {
+			storage := &failingStorage{MemoryStorage: NewMemoryStorage()}
+			rl := NewRemoteListWithStorage(storage)
+			if err := rl.AppendMany(AppendManyArgs{ListID: 1, Values: []int{1, 2, 3}}, &AppendManyReply{}); err != nil {
+				t.Fatal(err)
+			}
+			before := lists(t, rl)
+
+			storage.fail = true
+			if err := op(rl); !errors.Is(err, errInjected) {
+				t.Fatalf("erro = %v, esperado %v", err, errInjected)
+			}
+			if got := lists(t, rl); !reflect.DeepEqual(got, before) {
+				t.Fatalf("memória mudou com o log falhando: %v, antes %v", got, before)
+			}
+
+			//o log volta: a operação passa e memória e log continuam iguais
+			storage.fail = false
+			if err := op(rl); err != nil {
+				t.Fatal(err)
+			}
+			reloaded := NewRemoteListWithStorage(storage)
+			if err := reloaded.LoadFromSnapshot(); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := lists(t, reloaded), lists(t, rl); !reflect.DeepEqual(got, want) {
+				t.Fatalf("replay = %v, memória = %v", got, want)
+			}
+		})
+	}
+}
+
+// um waiter do BlockingPop atendido por um commit que falha não recebe valor nenhum
+func TestBlockingPopServeFailure(t *testing.T) {
+	storage := &failingStorage{MemoryStorage: NewMemoryStorage()}
+	rl := NewRemoteListWithStorage(storage)
+	if err := rl.CreateList(CreateListArgs{ListID: 1}, &CreateListReply{}); err != nil {
+		t.Fatal(err)
+	}
+	var reply BlockingPopReply
+	done := make(chan error)
+	go func() {
+		done <- rl.BlockingPop(BlockingPopArgs{ListIDs: []int{1}, Timeout: 200 * time.Millisecond}, &reply)
+	}()
+	for rl.waiting.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	storage.fail = true
+	if err := rl.Append(AppendArgs{ListID: 1, Value: 5}, &AppendReply{}); !errors.Is(err, errInjected) {
+		t.Fatalf("Append: erro = %v", err)
+	}
+	if err := <-done; err != nil || !reply.TimedOut {
+		t.Fatalf("BlockingPop = %+v, %v; esperado timeout", reply, err)
+	}
+}
@@ -0,0 +1,356 @@
+package remotelist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxGroupCommit limita quantas entradas o escritor junta numa única escrita
+const maxGroupCommit = 256
+
+var errLogClosed = errors.New("log fechado")
+
+// logRequest é uma entrada esperando o escritor do log
+type logRequest struct {
+	entry LogEntry
+	done  chan error
+}
+
+// --- AppendRecord (thread-safe, group commit) ---
+// enfileira a entrada e espera o escritor confirmar a gravação (e o fsync, em
+// SyncAlways); então preenche LSN e timestamp. Handlers concorrentes dividem a
+// mesma escrita.
+func (fs *FileStorage) AppendRecord(entry *LogEntry) error {
+	req := &logRequest{entry: *entry, done: make(chan error, 1)}
+
+	fs.logMutex.Lock()
+	if fs.logClosed {
+		fs.logMutex.Unlock()
+		return errLogClosed
+	}
+	fs.logCh <- req
+	fs.logMutex.Unlock()
+
+	if err := <-req.done; err != nil {
+		return err
+	}
+	entry.LSN = req.entry.LSN
+	entry.Timestamp = req.entry.Timestamp
+	return nil
+}
+
+// --- logWriter (goroutine única que grava no segmento ativo) ---
+func (fs *FileStorage) logWriter() {
+	defer close(fs.logDone)
+
+	batch := make([]*logRequest, 0, maxGroupCommit)
+	for req := range fs.logCh {
+		batch = append(batch[:0], req)
+		//junta o que já estiver na fila, sem esperar por mais
+	drain:
+		for len(batch) < maxGroupCommit {
+			select {
+			case r, ok := <-fs.logCh:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, r)
+			default:
+				break drain
+			}
+		}
+		fs.writeBatch(batch)
+	}
+}
+
+// writeBatch numera o lote, codifica no formato do segmento ativo e grava tudo
+// com uma única escrita (e um fsync em SyncAlways); depois acorda cada handler.
+// Como o LSN só é atribuído aqui, a ordem no arquivo é a ordem do LSN e um lote
+// que falha não deixa buraco na numeração.
+// Um segmento que já atingiu o tamanho máximo é fechado antes e o lote vai para o próximo.
+func (fs *FileStorage) writeBatch(batch []*logRequest) {
+	fs.fileMu.Lock()
+	written := make([]*logRequest, 0, len(batch))
+	err := fs.logFailed
+	if max := fs.opts.segmentSize; err == nil && max > 0 && fs.segBytes[fs.logSeg] >= max {
+		err = fs.rotateLocked()
+	}
+	if err == nil {
+		err = fs.openLogLocked()
+	}
+	if err == nil {
+		var buf []byte
+		lsn := fs.lastLSN
+		now := time.Now().UnixNano()
+		for _, r := range batch {
+			r.entry.LSN = lsn + 1
+			r.entry.Timestamp = now
+			rec, encErr := encodeEntry(&r.entry, fs.logFormat)
+			if encErr != nil {
+				r.done <- encErr
+				continue
+			}
+			lsn++
+			buf = append(buf, rec...)
+			written = append(written, r)
+		}
+		if len(written) > 0 {
+			if err = fs.writeLocked(buf); err == nil {
+				fs.lastLSN = lsn
+				fs.segMaxLSN[fs.logSeg] = lsn
+			}
+		}
+	} else {
+		written = batch
+	}
+	fs.fileMu.Unlock()
+
+	for _, r := range written {
+		r.done <- err
+	}
+}
+
+// openLogLocked abre o segmento ativo (que fica aberto até a próxima rotação ou
+// Close). Segmento novo recebe o formato configurado; um já existente mantém o
+// formato em que foi criado. Assume fileMu travado.
+func (fs *FileStorage) openLogLocked() error {
+	if fs.logFD != nil {
+		return nil
+	}
+	f, err := os.OpenFile(fs.segmentPath(fs.logSeg), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	format := fs.opts.format
+	if st.Size() == 0 {
+		//segmento novo: a entrada no diretório precisa sobreviver a uma queda junto com os registros
+		if err := syncDir(filepath.Dir(fs.logFile)); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if format == FormatBinary {
+			hdr := binaryHeader(logMagic)
+			if _, err := f.Write(hdr); err != nil {
+				_ = f.Close()
+				return err
+			}
+			fs.segBytes[fs.logSeg] = int64(len(hdr))
+		}
+	} else {
+		hdr := make([]byte, len(logMagic)+1)
+		n, _ := f.ReadAt(hdr, 0)
+		if format, _, err = detectFormat(hdr[:n], logMagic); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	fs.logFD = f
+	fs.logFormat = format
+	return nil
+}
+
+// writeLocked grava buf no segmento ativo. Se a escrita ou o fsync falhar, o
+// segmento volta ao tamanho de antes: os handlers recebem erro e não aplicam
+// nada, então nada do lote pode reaparecer no replay. Assume fileMu travado.
+func (fs *FileStorage) writeLocked(buf []byte) error {
+	before := fs.segBytes[fs.logSeg]
+	_, err := fs.logFD.Write(buf)
+	if err == nil && fs.opts.syncMode == SyncAlways {
+		err = fs.logFD.Sync()
+	}
+	if err != nil {
+		fs.rollbackLocked(before, err)
+		return err
+	}
+	fs.segBytes[fs.logSeg] += int64(len(buf))
+	fs.logDirty = fs.opts.syncMode != SyncAlways
+	return nil
+}
+
+// rollbackLocked trunca o segmento ativo em size depois de uma escrita que falhou.
+// Se nem isso der certo, o segmento pode ter um registro pela metade no meio do
+// log: o log passa a recusar novas entradas até o servidor reiniciar (a carga
+// trata o resto como cauda rasgada). Assume fileMu travado.
+func (fs *FileStorage) rollbackLocked(size int64, cause error) {
+	err := fs.logFD.Truncate(size)
+	if err == nil {
+		err = fs.logFD.Sync()
+	}
+	if err != nil {
+		fs.logFailed = fmt.Errorf("log desativado: escrita falhou (%v) e não pôde ser desfeita (%v)", cause, err)
+		fmt.Println("[Log]", fs.logFailed)
+	}
+}
+
+// encodeEntry serializa um registro completo (com crc/tamanho) no formato pedido
+func encodeEntry(e *LogEntry, format Format) ([]byte, error) {
+	if format == FormatBinary {
+		return encodeEntryBinary(e)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return encodeRecord(data), nil
+}
+
+// --- syncLoop (fsync periódico em SyncInterval) ---
+func (fs *FileStorage) syncLoop() {
+	ticker := time.NewTicker(fs.opts.syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fs.stopSync:
+			return
+		case <-ticker.C:
+			fs.fileMu.Lock()
+			if fs.logFD != nil && fs.logDirty {
+				if err := fs.logFD.Sync(); err != nil {
+					fmt.Println("[Log] erro no fsync periódico:", err)
+				} else {
+					fs.logDirty = false
+				}
+			}
+			fs.fileMu.Unlock()
+		}
+	}
+}
+
+// logFile é o que o escritor usa do segmento aberto (um *os.File; os testes
+// trocam por um que falha)
+type logFile interface {
+	Write(b []byte) (int, error)
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+// rotateLocked sela o segmento ativo; a próxima escrita abre o seguinte. Assume fileMu travado.
+func (fs *FileStorage) rotateLocked() error {
+	if err := fs.closeLogLocked(); err != nil {
+		return err
+	}
+	fs.logSeg++
+	return nil
+}
+
+// closeLogLocked sincroniza e fecha o segmento ativo. Assume fileMu travado.
+func (fs *FileStorage) closeLogLocked() error {
+	if fs.logFD == nil {
+		return nil
+	}
+	err := fs.logFD.Sync()
+	if cerr := fs.logFD.Close(); err == nil {
+		err = cerr
+	}
+	fs.logFD = nil
+	fs.logDirty = false
+	return err
+}
+
+// --- Close (esvazia a fila, sincroniza e fecha o log; chamar no encerramento do servidor) ---
+func (fs *FileStorage) Close() error {
+	var err error
+	fs.closeOnce.Do(func() {
+		fs.logMutex.Lock()
+		fs.logClosed = true
+		close(fs.logCh)
+		fs.logMutex.Unlock()
+		<-fs.logDone
+
+		close(fs.stopSync)
+		fs.fileMu.Lock()
+		err = fs.closeLogLocked()
+		fs.fileMu.Unlock()
+	})
+	return err
+}
+
+// --- leitura e verificação de um segmento ---
+type segmentData struct {
+	seq        int
+	entries    []LogEntry //entradas válidas antes do primeiro problema
+	validEnd   int        //offset logo após a última entrada válida
+	badOffset  int        //offset do primeiro registro inválido (-1 se nenhum)
+	badRecord  int
+	badReason  string
+	validAfter int //registros válidos depois do problema (0 = só a cauda está ruim)
+}
+
+// readSegment decodifica um segmento JSONL ou binário (detectado pelo cabeçalho).
+// Depois do primeiro registro inválido continua procurando registros válidos só
+// para distinguir uma cauda rasgada de corrupção no meio do arquivo.
+func readSegment(seq int, data []byte) (segmentData, error) {
+	sd := segmentData{seq: seq, badOffset: -1}
+	format, hdr, err := detectFormat(data, logMagic)
+	if err != nil {
+		return sd, err
+	}
+	decode := decodeTextAt
+	if format == FormatBinary {
+		decode = decodeBinaryAt
+	}
+
+	sd.validEnd = hdr
+	rec := 0
+	for off := hdr; off < len(data); {
+		entry, next, err := decode(data, off)
+		if err == nil && entry == nil { //linha vazia
+			if sd.badOffset < 0 {
+				sd.validEnd = next
+			}
+			off = next
+			continue
+		}
+		rec++
+		switch {
+		case err == nil && sd.badOffset >= 0:
+			sd.validAfter++
+		case err == nil:
+			sd.entries = append(sd.entries, *entry)
+			sd.validEnd = next
+		case sd.badOffset < 0:
+			sd.badOffset = off
+			sd.badRecord = rec
+			sd.badReason = err.Error()
+		}
+		if err != nil && format == FormatBinary {
+			//sem delimitador confiável: tenta ressincronizar byte a byte
+			next = off + 1
+		}
+		off = next
+	}
+	return sd, nil
+}
+
+// --- CorruptionError (log corrompido fora da cauda) ---
+type CorruptionError struct {
+	Segment    string
+	Record     int //número do registro no segmento (a linha, no JSONL)
+	Offset     int
+	Reason     string
+	ValidAfter int //entradas válidas encontradas depois do ponto corrompido
+}
+
+func (e *CorruptionError) Error() string {
+	return fmt.Sprintf("log corrompido em %s (registro %d, byte %d): %s; %d entradas válidas depois desse ponto não foram aplicadas. "+
+		"Reinicie com reparo para truncar o log no ponto corrompido (o trecho descartado é guardado em *.corrupt)",
+		e.Segment, e.Record, e.Offset, e.Reason, e.ValidAfter)
+}
+
+// quarantine move data[from:] de path para path.corrupt e trunca path em from
+func quarantine(path string, data []byte, from int) error {
+	if err := writeFileSync(path+".corrupt", data[from:]); err != nil {
+		return err
+	}
+	return os.Truncate(path, int64(from))
+}
@@ -0,0 +1,210 @@
+package remotelist
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// faultyFile troca o segmento aberto do log por um que falha
+type faultyFile struct {
+	logFile
+	shortWrite   bool //grava só metade e retorna erro
+	syncFails    int  //quantos Sync seguidos falham
+	failTruncate bool
+}
+
+func (f *faultyFile) Write(b []byte) (int, error) {
+	if f.shortWrite {
+		n, _ := f.logFile.Write(b[:len(b)/2])
+		return n, errInjected
+	}
+	return f.logFile.Write(b)
+}
+
+func (f *faultyFile) Sync() error {
+	if f.syncFails > 0 {
+		f.syncFails--
+		return errInjected
+	}
+	return f.logFile.Sync()
+}
+
+func (f *faultyFile) Truncate(size int64) error {
+	if f.failTruncate {
+		return errInjected
+	}
+	return f.logFile.Truncate(size)
+}
+
+// openFileList abre uma RemoteList sobre FileStorage em base e devolve os dois
+func openFileList(t *testing.T, base string, opts ...Option) (*RemoteList, *FileStorage) {
+	t.Helper()
+	fs := NewFileStorage(base, opts...)
+	rl := NewRemoteListWithStorage(fs, opts...)
+	if err := rl.LoadFromSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	return rl, fs
+}
+
+// injectFault põe f na frente do segmento aberto (que precisa já estar aberto)
+func injectFault(fs *FileStorage, f *faultyFile) {
+	fs.fileMu.Lock()
+	defer fs.fileMu.Unlock()
+	f.logFile = fs.logFD
+	fs.logFD = f
+}
+
+func removeFault(fs *FileStorage, f *faultyFile) {
+	fs.fileMu.Lock()
+	defer fs.fileMu.Unlock()
+	fs.logFD = f.logFile
+}
+
+func appendValues(t *testing.T, rl *RemoteList, id int, values ...int) {
+	t.Helper()
+	for _, v := range values {
+		if err := rl.Append(AppendArgs{ListID: id, Value: v}, &AppendReply{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// uma escrita que falha no meio (ou cujo fsync falha) não pode deixar nada no
+// segmento: o restart tem que carregar sem corrupção e sem a entrada recusada
+func TestWriteFailureIsRolledBack(t *testing.T) {
+	cases := map[string]faultyFile{
+		"escrita parcial": {shortWrite: true},
+		"fsync":           {syncFails: 1},
+	}
+	for name, fault := range cases {
+		for _, format := range []Format{FormatJSON, FormatBinary} {
+			t.Run(name+"/"+format.String(), func(t *testing.T) {
+				base := filepath.Join(t.TempDir(), "d")
+				opts := []Option{WithFormat(format), WithSync(SyncAlways, 0)}
+				rl, fs := openFileList(t, base, opts...)
+				appendValues(t, rl, 1, 0, 1, 2)
+
+				f := fault
+				injectFault(fs, &f)
+				if err := rl.Append(AppendArgs{ListID: 1, Value: 100}, &AppendReply{}); !errors.Is(err, errInjected) {
+					t.Fatalf("erro = %v, esperado %v", err, errInjected)
+				}
+				removeFault(fs, &f)
+				appendValues(t, rl, 1, 3)
+				want := lists(t, rl)
+				if err := rl.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				reloaded, _ := openFileList(t, base, opts...)
+				defer reloaded.Close()
+				if got := lists(t, reloaded); !reflect.DeepEqual(got, want) {
+					t.Fatalf("replay = %v, memória antes do restart = %v", got, want)
+				}
+				if !reflect.DeepEqual(want[1], []int{0, 1, 2, 3}) {
+					t.Fatalf("lista = %v", want[1])
+				}
+			})
+		}
+	}
+}
+
+// se nem o truncate funciona, o log para de aceitar entradas: nada é gravado
+// depois do registro pela metade, que o restart descarta como cauda rasgada
+func TestFailedRollbackDisablesLog(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "d")
+	rl, fs := openFileList(t, base)
+	appendValues(t, rl, 1, 0, 1)
+
+	f := faultyFile{shortWrite: true, failTruncate: true}
+	injectFault(fs, &f)
+	if err := rl.Append(AppendArgs{ListID: 1, Value: 100}, &AppendReply{}); !errors.Is(err, errInjected) {
+		t.Fatalf("erro = %v, esperado %v", err, errInjected)
+	}
+	removeFault(fs, &f)
+	if err := rl.Append(AppendArgs{ListID: 1, Value: 2}, &AppendReply{}); err == nil {
+		t.Fatal("log aceitou entrada depois de uma escrita que não pôde ser desfeita")
+	}
+	want := lists(t, rl)
+	rl.Close()
+
+	reloaded, _ := openFileList(t, base)
+	defer reloaded.Close()
+	if got := lists(t, reloaded); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replay = %v, memória antes do restart = %v", got, want)
+	}
+}
+
+// --- benchmark: group commit x escrita serializada por entrada ---
+
+// legacyStorage reproduz o appendToLog antigo: um mutex global e, por entrada,
+// abrir o arquivo, escrever uma linha JSON (com fsync em SyncAlways) e fechar
+type legacyStorage struct {
+	*MemoryStorage
+	path string
+	sync bool
+	mu   sync.Mutex
+}
+
+func (s *legacyStorage) AppendRecord(entry *LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.Timestamp = time.Now().UnixNano()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if err == nil && s.sync {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// BenchmarkAppend mede Append concorrente (8 goroutines por CPU, cada uma na
+// sua lista) com o group commit e com a escrita antiga, em SyncAlways e SyncOS
+func BenchmarkAppend(b *testing.B) {
+	modes := []SyncMode{SyncAlways, SyncOS}
+	for _, mode := range modes {
+		b.Run("group/"+mode.String(), func(b *testing.B) {
+			fs := NewFileStorage(filepath.Join(b.TempDir(), "d"), WithSync(mode, 0))
+			benchmarkAppend(b, NewRemoteListWithStorage(fs))
+			fs.Close()
+		})
+		b.Run("legacy/"+mode.String(), func(b *testing.B) {
+			s := &legacyStorage{MemoryStorage: NewMemoryStorage(), path: filepath.Join(b.TempDir(), "d.log"), sync: mode == SyncAlways}
+			benchmarkAppend(b, NewRemoteListWithStorage(s))
+		})
+	}
+}
+
+func benchmarkAppend(b *testing.B, rl *RemoteList) {
+	var nextID atomic.Int64
+	b.SetParallelism(8)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := int(nextID.Add(1))
+		for i := 0; pb.Next(); i++ {
+			if err := rl.Append(AppendArgs{ListID: id, Value: i}, &AppendReply{}); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+}